@@ -5,6 +5,7 @@ import (
 	"io"
 	"io/ioutil"
 	"reflect"
+	"strconv"
 	"strings"
 )
 
@@ -45,10 +46,22 @@ func MustLoad(r io.Reader, unmarshal Unmarshaler) Config {
 // a hierarchy of configuration values a dot can be used to separate the
 // different levels (e.g. "foo.bar" gets the value of the key 'bar' which lies
 // under the key 'foo').
+//
+// Elements of slices and arrays are accessed by their index, either in
+// brackets or as a separate level (e.g. "servers[0].port" and
+// "servers.0.port" are equivalent). Negative indexes count from the end, so
+// "servers[-1]" is the last element. If an index is out of range an error
+// is returned.
+//
+// Keys which contain dots or brackets themselves can be given in double
+// quotes, e.g. `hosts."example.com".port`. Within quotes a backslash
+// escapes a quote or a backslash; outside of quotes it escapes any
+// character (e.g. `hosts.example\.com.port`). See Path for building keys
+// from segments.
 func (c Config) Value(key string) (*Value, error) {
-	val := c.value(key)
-	if !val.IsValid() {
-		return nil, fmt.Errorf("key not found: %s", key)
+	val, err := c.value(key)
+	if err != nil {
+		return nil, err
 	}
 	v := Value(val)
 	return &v, nil
@@ -90,25 +103,91 @@ func (c Config) Decode(key string, value interface{}) error {
 	return nil
 }
 
-func (c Config) value(configKey string) reflect.Value {
+// Overlay returns a new configuration which contains all values of c
+// overridden by the values of o. Nested maps are merged recursively, all
+// other values of o replace the values of c. Keys of o which differ from the
+// keys of c only in case override the values of c (see Merger.Merge).
+// Neither c nor o are modified.
+//
+// Overlay is a shortcut for Merge(c, o).
+func (c Config) Overlay(o Config) Config {
+	return Merge(c, o)
+}
+
+// value returns the configuration value with the given key. An error is
+// returned if the key is invalid, does not exist or if an index is out of
+// range.
+func (c Config) value(configKey string) (reflect.Value, error) {
+	segs, err := parseKey(configKey)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
 	val := reflect.ValueOf(c)
-	keys := strings.Split(configKey, ".")
-	nKeys := len(keys)
-	for i := 0; i < nKeys-1; i++ {
-		key := reflect.ValueOf(keys[i])
-		if !key.Type().AssignableTo(val.Type().Key()) {
-			return reflect.Value{}
+	for _, seg := range segs {
+		var n int
+		if val, n = child(val, seg); !val.IsValid() {
+			if n >= 0 {
+				return reflect.Value{}, fmt.Errorf("index out of range: %s (length %d)", configKey[:seg.end], n)
+			}
+			return reflect.Value{}, fmt.Errorf("key not found: %s", configKey)
 		}
+	}
+	return val, nil
+}
+
+// child returns the value of the map key or the slice element of val which
+// is selected by seg. If there is no such value, an invalid value is
+// returned along with the length of val if the index is out of range, or
+// -1 otherwise.
+func child(val reflect.Value, seg keySegment) (reflect.Value, int) {
+	val = unwrap(val)
+	switch val.Kind() {
+	case reflect.Map:
+		if seg.index {
+			return reflect.Value{}, -1
+		}
+		return mapIndex(val, seg.name), -1
 
-		val = val.MapIndex(key)
-		if val.Kind() == reflect.Interface && !val.IsNil() {
-			val = val.Elem()
+	case reflect.Slice, reflect.Array:
+		idx, err := strconv.Atoi(seg.name)
+		if err != nil {
+			return reflect.Value{}, -1
+		}
+		n := val.Len()
+		if idx < 0 {
+			idx += n
+		}
+		if idx < 0 || idx >= n {
+			return reflect.Value{}, n
 		}
-		if !val.IsValid() || val.Kind() != reflect.Map {
-			return reflect.Value{}
+		return val.Index(idx), -1
+	}
+	return reflect.Value{}, -1
+}
+
+// insert stores v in m under the hierarchy given by keys. Missing
+// intermediate maps are created. An error is returned if one of the
+// intermediate keys holds a value which is not a map, or if the last key
+// holds a map.
+func insert(m map[string]interface{}, keys []string, v interface{}) error {
+	for i, key := range keys[:len(keys)-1] {
+		switch next := m[key].(type) {
+		case nil:
+			nm := make(map[string]interface{})
+			m[key] = nm
+			m = nm
+		case map[string]interface{}:
+			m = next
+		default:
+			return fmt.Errorf("key '%s' is not a map", strings.Join(keys[:i+1], "."))
 		}
 	}
 
-	key := reflect.ValueOf(keys[nKeys-1])
-	return val.MapIndex(key)
+	key := keys[len(keys)-1]
+	if _, isMap := m[key].(map[string]interface{}); isMap {
+		return fmt.Errorf("key '%s' is a map", strings.Join(keys, "."))
+	}
+	m[key] = v
+	return nil
 }
@@ -133,6 +133,70 @@ func TestConfigValue(t *testing.T) {
 	}
 }
 
+func TestConfigValueIndex(t *testing.T) {
+	c := Config{
+		"servers": []interface{}{
+			map[string]interface{}{"host": "a", "port": 80},
+			map[string]interface{}{"host": "b", "port": 81},
+		},
+		"matrix": [][]int{{1, 2}, {3, 4}},
+		"ports":  [2]int{8080, 8081},
+	}
+
+	tests := map[string]string{
+		"servers[0].port":  "80",
+		"servers.0.port":   "80",
+		"servers[1].host":  "b",
+		"servers[-1].host": "b",
+		"servers.-2.host":  "a",
+		"matrix[1][0]":     "3",
+		"matrix.0.-1":      "2",
+		"ports[1]":         "8081",
+	}
+	for key, expected := range tests {
+		v, err := c.Value(key)
+		if err != nil {
+			t.Fatalf("unexpected error for key %s: %v", key, err)
+		}
+		s, err := v.String()
+		if err != nil {
+			t.Fatalf("unexpected error for key %s: %v", key, err)
+		}
+		if s != expected {
+			t.Fatalf("unexpected value for key %s: %s", key, s)
+		}
+	}
+
+	var port int
+	if err := c.Decode("servers[1].port", &port); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if port != 81 {
+		t.Fatalf("unexpected port: %d", port)
+	}
+
+	errs := map[string]string{
+		"servers[2].port":  "index out of range: servers[2] (length 2)",
+		"servers.-3.port":  "index out of range: servers.-3 (length 2)",
+		"matrix[0][2]":     "index out of range: matrix[0][2] (length 2)",
+		"servers.first":    "key not found: servers.first",
+		"servers[0][0]":    "key not found: servers[0][0]",
+		"servers[0].port.": "key not found: servers[0].port.",
+		"servers[a]":       "invalid key 'servers[a]': invalid index 'a'",
+		"servers[0":        "invalid key 'servers[0': missing ']'",
+		"servers[0]port":   "invalid key 'servers[0]port': unexpected 'p' at position 10",
+	}
+	for key, expected := range errs {
+		_, err := c.Value(key)
+		if err == nil {
+			t.Fatalf("expected error for key %s, got none", key)
+		}
+		if err.Error() != expected {
+			t.Fatalf("unexpected error for key %s: %v", key, err)
+		}
+	}
+}
+
 func TestConfigDecode(t *testing.T) {
 	c := Config{
 		"foo": map[string]interface{}{
@@ -15,6 +15,8 @@ const (
 	minInt  = -maxInt - 1
 )
 
+var timeType = reflect.TypeOf(time.Time{})
+
 func decode(output, input reflect.Value) error {
 	if input.Kind() == reflect.Interface && !input.IsNil() {
 		input = input.Elem()
@@ -80,6 +82,9 @@ func decode(output, input reflect.Value) error {
 		return decodeInterface(output, input)
 
 	case reflect.Struct:
+		if output.Type() == timeType {
+			return decodeTime(output, input)
+		}
 		return decodeStruct(output, input)
 
 	case reflect.Ptr:
@@ -215,7 +220,7 @@ func decodeDuration(output, input reflect.Value) error {
 	case reflect.String:
 		d, err := time.ParseDuration(input.String())
 		if err != nil {
-			return fmt.Errorf("'%s' is not a valid duration", input.String())
+			return fmt.Errorf("'%s' is not a valid duration", displayString(input))
 		}
 		output.SetInt(int64(d))
 
@@ -226,6 +231,28 @@ func decodeDuration(output, input reflect.Value) error {
 	return nil
 }
 
+func decodeTime(output, input reflect.Value) error {
+	switch input.Kind() {
+	case reflect.Struct:
+		if input.Type() != timeType {
+			return fmt.Errorf("'%s' could not be converted to 'time.Time'", input.Type())
+		}
+		output.Set(input)
+
+	case reflect.String:
+		t, err := time.Parse(time.RFC3339Nano, input.String())
+		if err != nil {
+			return fmt.Errorf("'%s' is not a valid time", displayString(input))
+		}
+		output.Set(reflect.ValueOf(t))
+
+	default:
+		return fmt.Errorf("'%s' could not be converted to 'time.Time'", input.Type())
+	}
+
+	return nil
+}
+
 func decodeFloat(output, input reflect.Value, max float64) error {
 	input = convertNumericString(input)
 
@@ -280,10 +307,20 @@ func decodeString(output, input reflect.Value) error {
 		output.SetString(strconv.FormatFloat(input.Float(), 'f', -1, 64))
 
 	case reflect.String:
+		if input.Type() != secretType && strings.HasPrefix(input.String(), SecretScheme) {
+			return fmt.Errorf("unresolved secret reference '%s' (see Config.ResolveSecrets)", input.String())
+		}
 		output.SetString(input.String())
 
 	default:
-		output.SetString(fmt.Sprintf("%v", input.Interface()))
+		switch v := input.Interface().(type) {
+		case time.Time:
+			output.SetString(v.Format(time.RFC3339Nano))
+		case []byte:
+			output.SetString(string(v))
+		default:
+			output.SetString(fmt.Sprintf("%v", v))
+		}
 	}
 
 	return nil
@@ -377,9 +414,8 @@ func decodeStruct(output, input reflect.Value) error {
 		return fmt.Errorf("'%s' could not be converted to 'map'", input.Type())
 	}
 
-	stringType := reflect.TypeOf("")
 	keyType := input.Type().Key()
-	if !stringType.AssignableTo(keyType) {
+	if !isStringableKind(keyType.Kind()) {
 		return fmt.Errorf("map[%s]' could not be converted to 'map[string]'", keyType)
 	}
 
@@ -388,14 +424,12 @@ func decodeStruct(output, input reflect.Value) error {
 			continue
 		}
 
-		key := reflect.ValueOf(field.mapkey())
-		val := input.MapIndex(key)
+		val := mapIndex(input, field.mapkey())
 		if !val.IsValid() && len(field.key) == 0 {
 			// map key not found and no key specified (search case-insensitive)
 			for _, k := range input.MapKeys() {
-				if s, ok := k.Interface().(string); ok {
+				if s, ok := keyString(k); ok {
 					if strings.EqualFold(s, field.name) {
-						key = k
 						val = input.MapIndex(k)
 						break
 					}
@@ -453,10 +487,72 @@ func convertNumericString(v reflect.Value) reflect.Value {
 	return v
 }
 
+// mapIndex returns the value of the map m with the given key. Besides maps
+// with string keys, maps with keys of other kinds are supported if the keys
+// can be represented as strings (see keyString), e.g. the key 1 of a
+// map[interface{}]interface{} is found with the name "1".
+func mapIndex(m reflect.Value, name string) reflect.Value {
+	keyType := m.Type().Key()
+	key := reflect.ValueOf(name)
+	switch {
+	case keyType.Kind() == reflect.String:
+		return m.MapIndex(key.Convert(keyType))
+	case key.Type().AssignableTo(keyType):
+		if val := m.MapIndex(key); val.IsValid() {
+			return val
+		}
+	case !isStringableKind(keyType.Kind()):
+		return reflect.Value{}
+	}
+
+	for _, k := range m.MapKeys() {
+		if s, ok := keyString(k); ok && s == name {
+			return m.MapIndex(k)
+		}
+	}
+	return reflect.Value{}
+}
+
+// keyString returns the string representation of the map key k. Only
+// strings, numbers and booleans can be represented as strings.
+func keyString(k reflect.Value) (string, bool) {
+	if k.Kind() == reflect.Interface && !k.IsNil() {
+		k = k.Elem()
+	}
+
+	switch k.Kind() {
+	case reflect.String:
+		return k.String(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(k.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(k.Float(), 'f', -1, 64), true
+	case reflect.Bool:
+		return strconv.FormatBool(k.Bool()), true
+	}
+	return "", false
+}
+
+// isStringableKind reports whether map keys of the given kind may be
+// represented as strings.
+func isStringableKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.String, reflect.Interface, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
 type field struct {
 	name     string
 	value    reflect.Value
 	key      string
+	usage    string
 	required bool
 	ignore   bool
 }
@@ -470,6 +566,7 @@ func fieldsOf(v reflect.Value) []*field {
 		f := &field{
 			name:  structField.Name,
 			value: v.Field(i),
+			usage: structField.Tag.Get("usage"),
 		}
 
 		tag := structField.Tag.Get("config")
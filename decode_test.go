@@ -250,6 +250,31 @@ func TestDecodeDuration(t *testing.T) {
 	invalidDecode(t, &d, struct{}{})
 }
 
+func TestDecodeTime(t *testing.T) {
+	var tm time.Time
+	ref := time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC)
+
+	validDecode(t, &tm, ref)
+	if !tm.Equal(ref) {
+		t.Fatalf("unexpected time value: %v", tm)
+	}
+
+	validDecode(t, &tm, "1979-05-27T08:32:00+01:00")
+	if !tm.Equal(ref) {
+		t.Fatalf("unexpected time value: %v", tm)
+	}
+
+	var s string
+	validDecode(t, &s, ref)
+	if s != "1979-05-27T07:32:00Z" {
+		t.Fatalf("unexpected string value: %s", s)
+	}
+
+	invalidDecode(t, &tm, "yesterday")
+	invalidDecode(t, &tm, 7)
+	invalidDecode(t, &tm, struct{}{})
+}
+
 func TestDecodeFloat(t *testing.T) {
 	var f32 float32
 
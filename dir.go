@@ -0,0 +1,95 @@
+package conf
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+)
+
+// DirOptions controls which files are loaded by LoadDir and how they are
+// merged.
+type DirOptions struct {
+	// Pattern selects the files to load (see filepath.Match). If empty,
+	// all files with a registered extension are loaded (see RegisterFormat).
+	// Files matching the pattern with an unknown extension cause an error.
+	Pattern string
+
+	// Merger defines how the files are merged.
+	Merger Merger
+}
+
+// FileError records an error which occurred while loading a specific
+// configuration file.
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// LoadDir loads all configuration files in the directory dir and merges
+// them into a single configuration (e.g. for "conf.d" directories). The
+// files are loaded in lexical order of their names, so later files take
+// precedence over earlier ones. Each file is loaded like LoadFile does,
+// choosing the parser by its extension. Subdirectories and hidden files
+// (starting with a dot) are ignored. If opts is nil the default options are
+// used. Errors of specific files are reported as *FileError.
+func LoadDir(dir string, opts *DirOptions) (Config, error) {
+	return loadDir(fileSystem{}, dir, opts)
+}
+
+// LoadDirFS is like LoadDir, but loads the files in the directory dir of
+// fsys (see LoadFS).
+func LoadDirFS(fsys fs.FS, dir string, opts *DirOptions) (Config, error) {
+	return loadDir(fileSystem{fsys}, dir, opts)
+}
+
+func loadDir(fsys fileSystem, dir string, opts *DirOptions) (Config, error) {
+	if opts == nil {
+		opts = &DirOptions{}
+	}
+
+	entries, err := fsys.readDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || name[0] == '.' {
+			continue
+		}
+
+		if opts.Pattern == "" {
+			if _, err := lookupFormat(filepath.Ext(name)); err != nil {
+				continue
+			}
+		} else if match, err := filepath.Match(opts.Pattern, name); err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %v", opts.Pattern, err)
+		} else if !match {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	layers := make([]Config, 0, len(names))
+	for _, name := range names {
+		path := fsys.join(dir, name)
+		c, err := loadFile(fsys, path, nil)
+		if err != nil {
+			return nil, &FileError{Path: path, Err: err}
+		}
+		layers = append(layers, c)
+	}
+	return opts.Merger.Merge(layers...), nil
+}
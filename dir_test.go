@@ -0,0 +1,79 @@
+package conf
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "10-base.json", `{ "server": { "port": 80, "address": "0.0.0.0" }, "tags": ["a"] }`)
+	writeTestFile(t, dir, "20-server.toml", "tags = [\"b\"]\n[server]\nport = 8080\n")
+	writeTestFile(t, dir, "30-local.ini", "[server]\naddress = 127.0.0.1\n")
+	writeTestFile(t, dir, "README", "not a configuration")
+	writeTestFile(t, dir, ".hidden.json", `{ "hidden": true }`)
+	writeTestFile(t, dir, "sub/99-ignored.json", `{ "ignored": true }`)
+
+	c, err := LoadDir(dir, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := Config{
+		"server": map[string]interface{}{
+			"port":    int64(8080),
+			"address": "127.0.0.1",
+		},
+		"tags": []interface{}{"b"},
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Fatalf("unexpected configuration: %#v", c)
+	}
+
+	c, err = LoadDir(dir, &DirOptions{Pattern: "*.json"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if port := c["server"].(map[string]interface{})["port"]; port != 80.0 {
+		t.Fatalf("unexpected port: %v", port)
+	}
+
+	c, err = LoadDir(dir, &DirOptions{Merger: Merger{Slices: SliceAppend}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tags := c["tags"]; !reflect.DeepEqual(tags, []interface{}{"a", "b"}) {
+		t.Fatalf("unexpected tags: %v", tags)
+	}
+}
+
+func TestLoadDirErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "10-valid.json", `{}`)
+	path := writeTestFile(t, dir, "20-invalid.toml", "foo")
+
+	_, err := LoadDir(dir, nil)
+	var ferr *FileError
+	if !errors.As(err, &ferr) || ferr.Path != path {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var serr *SyntaxError
+	if !errors.As(err, &serr) || serr.Line != 1 {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// matching file with unknown extension
+	writeTestFile(t, dir, "README", "")
+	_, err = LoadDir(dir, &DirOptions{Pattern: "README"})
+	if !errors.As(err, &ferr) || ferr.Path != filepath.Join(dir, "README") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err = LoadDir(dir, &DirOptions{Pattern: "["}); err == nil {
+		t.Fatalf("expected error, got none")
+	}
+	if _, err = LoadDir(filepath.Join(dir, "missing"), nil); err == nil {
+		t.Fatalf("expected error, got none")
+	}
+}
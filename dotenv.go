@@ -0,0 +1,317 @@
+package conf
+
+import (
+	"os"
+	"strings"
+)
+
+// Dotenv parses the dotenv (.env) encoded data and stores the result in
+// value. It can be passed to Load or registered with RegisterFormat. All
+// keys are stored at the top level as they are written in the file (see
+// NestedDotenv for hierarchical keys).
+//
+// Each line contains an assignment "KEY=value" with an optional "export "
+// prefix. Lines starting with '#' are comments, as well as everything
+// following a '#' which is preceded by whitespace in unquoted values. Values
+// in single quotes are taken literally. Values in double quotes support the
+// escape sequences \n, \r, \t, \", \\ and \$. Both kinds of quoted values
+// may span multiple lines. Unquoted and double-quoted values expand the
+// variable references $VAR, ${VAR} and ${VAR:-default}, where VAR is looked
+// up in the previous assignments of the file and then in the process
+// environment. All values are stored as strings. Syntax errors are reported
+// as *SyntaxError.
+func Dotenv(data []byte, value interface{}) error {
+	m, err := parseDotenv(string(data), "", nil)
+	if err != nil {
+		return err
+	}
+	return storeMap(m, value)
+}
+
+// NestedDotenv returns an Unmarshaler for dotenv (.env) encoded data which
+// maps the keys to a hierarchy. Each key is converted to lower case and
+// split at sep, e.g. with the separator "__" the assignment SERVER__PORT=8080
+// is accessible via the key "server.port". Besides that the data is parsed
+// as described for Dotenv.
+func NestedDotenv(sep string) Unmarshaler {
+	return func(data []byte, value interface{}) error {
+		m, err := parseDotenv(string(data), sep, nil)
+		if err != nil {
+			return err
+		}
+		return storeMap(m, value)
+	}
+}
+
+type dotenvParser struct {
+	data string
+	pos  int
+	vars map[string]string
+}
+
+// parseDotenv parses the dotenv encoded data. If sep is not empty the keys
+// are nested as described for NestedDotenv. If lines is not nil, the line of
+// each key is stored in it.
+func parseDotenv(data, sep string, lines map[string]int) (map[string]interface{}, error) {
+	p := &dotenvParser{
+		data: data,
+		vars: make(map[string]string),
+	}
+
+	lc := lineCounter{data: data}
+	m := make(map[string]interface{})
+	for {
+		p.skip(" \t\r\n")
+		if p.eof() {
+			return m, nil
+		}
+		if p.peek() == '#' {
+			p.skipLine()
+			continue
+		}
+
+		keyPos := p.pos
+		key, val, err := p.parseAssignment()
+		if err != nil {
+			return nil, err
+		}
+		p.vars[key] = val
+
+		if sep == "" {
+			m[key] = val
+			if lines != nil {
+				lines[key] = lc.lineAt(keyPos)
+			}
+			continue
+		}
+		keys := strings.Split(strings.ToLower(key), sep)
+		for _, k := range keys {
+			if k == "" {
+				return nil, p.errorAt(keyPos, "invalid nested key '%s'", key)
+			}
+		}
+		if err := insert(m, keys, val); err != nil {
+			return nil, p.errorAt(keyPos, "%v", err)
+		}
+		if lines != nil {
+			lines[strings.Join(keys, ".")] = lc.lineAt(keyPos)
+		}
+	}
+}
+
+func (p *dotenvParser) parseAssignment() (string, string, error) {
+	if strings.HasPrefix(p.data[p.pos:], "export") {
+		if rest := p.data[p.pos+6:]; rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+			p.pos += 6
+			p.skip(" \t")
+		}
+	}
+
+	start := p.pos
+	for !p.eof() && isDotenvKeyChar(p.peek()) {
+		p.pos++
+	}
+	key := p.data[start:p.pos]
+	if key == "" {
+		return "", "", p.errorf("expected key")
+	}
+
+	p.skip(" \t")
+	if p.eof() || p.peek() != '=' {
+		return "", "", p.errorf("expected '=' after key")
+	}
+	p.pos++
+	p.skip(" \t")
+
+	var (
+		val string
+		err error
+	)
+	switch {
+	case p.eof():
+	case p.peek() == '\'':
+		val, err = p.parseSingleQuoted()
+	case p.peek() == '"':
+		val, err = p.parseDoubleQuoted()
+	default:
+		val, err = p.parseUnquoted()
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	// only comments may follow a value
+	p.skip(" \t")
+	if !p.eof() && p.peek() != '\n' && p.peek() != '\r' {
+		if p.peek() != '#' {
+			return "", "", p.errorf("unexpected character '%c' after value", p.peek())
+		}
+		p.skipLine()
+	}
+	return key, val, nil
+}
+
+func (p *dotenvParser) parseSingleQuoted() (string, error) {
+	start := p.pos
+	p.pos++ // '\''
+
+	end := strings.IndexByte(p.data[p.pos:], '\'')
+	if end < 0 {
+		return "", p.errorAt(start, "unterminated quoted value")
+	}
+	val := p.data[p.pos : p.pos+end]
+	p.pos += end + 1
+	return val, nil
+}
+
+func (p *dotenvParser) parseDoubleQuoted() (string, error) {
+	start := p.pos
+	p.pos++ // '"'
+
+	var sb strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorAt(start, "unterminated quoted value")
+		}
+
+		switch c := p.peek(); c {
+		case '"':
+			p.pos++
+			return sb.String(), nil
+
+		case '\\':
+			if p.pos+1 == len(p.data) {
+				return "", p.errorAt(start, "unterminated quoted value")
+			}
+			switch e := p.data[p.pos+1]; e {
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case '"', '\\', '$':
+				sb.WriteByte(e)
+			default:
+				return "", p.errorf("invalid escape sequence '\\%c'", e)
+			}
+			p.pos += 2
+
+		case '$':
+			if err := p.expand(&sb); err != nil {
+				return "", err
+			}
+
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+func (p *dotenvParser) parseUnquoted() (string, error) {
+	var sb strings.Builder
+	for !p.eof() {
+		c := p.peek()
+		switch {
+		case c == '\n' || c == '\r':
+			return strings.TrimRight(sb.String(), " \t"), nil
+		case c == '#' && p.pos > 0 && (p.data[p.pos-1] == ' ' || p.data[p.pos-1] == '\t'):
+			return strings.TrimRight(sb.String(), " \t"), nil
+		case c == '$':
+			if err := p.expand(&sb); err != nil {
+				return "", err
+			}
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+	return strings.TrimRight(sb.String(), " \t"), nil
+}
+
+// expand expands the variable reference at the current position and
+// writes the result to sb. If there is no valid reference a single '$'
+// is written.
+func (p *dotenvParser) expand(sb *strings.Builder) error {
+	start := p.pos
+	p.pos++ // '$'
+
+	if p.eof() || p.peek() != '{' {
+		nameStart := p.pos
+		for !p.eof() && isDotenvNameChar(p.peek()) {
+			p.pos++
+		}
+		if p.pos == nameStart {
+			sb.WriteByte('$')
+			return nil
+		}
+		sb.WriteString(p.lookup(p.data[nameStart:p.pos]))
+		return nil
+	}
+
+	end := strings.IndexByte(p.data[p.pos:], '}')
+	if end < 0 {
+		return p.errorAt(start, "unterminated variable reference")
+	}
+	ref := p.data[p.pos+1 : p.pos+end]
+	p.pos += end + 1
+
+	name, def, hasDefault := ref, "", false
+	if i := strings.Index(ref, ":-"); i >= 0 {
+		name, def, hasDefault = ref[:i], ref[i+2:], true
+	}
+	if name == "" || strings.IndexFunc(name, func(r rune) bool { return r > 0x7f || !isDotenvNameChar(byte(r)) }) >= 0 {
+		return p.errorAt(start, "invalid variable reference '${%s}'", ref)
+	}
+
+	val := p.lookup(name)
+	if val == "" && hasDefault {
+		val = def
+	}
+	sb.WriteString(val)
+	return nil
+}
+
+func (p *dotenvParser) lookup(name string) string {
+	if val, has := p.vars[name]; has {
+		return val
+	}
+	return os.Getenv(name)
+}
+
+func (p *dotenvParser) skip(chars string) {
+	for !p.eof() && strings.IndexByte(chars, p.peek()) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *dotenvParser) skipLine() {
+	for !p.eof() && p.peek() != '\n' {
+		p.pos++
+	}
+}
+
+func (p *dotenvParser) peek() byte {
+	return p.data[p.pos]
+}
+
+func (p *dotenvParser) eof() bool {
+	return p.pos >= len(p.data)
+}
+
+func (p *dotenvParser) errorf(msg string, args ...interface{}) error {
+	return p.errorAt(p.pos, msg, args...)
+}
+
+func (p *dotenvParser) errorAt(pos int, msg string, args ...interface{}) error {
+	return syntaxError("dotenv", p.data, pos, msg, args...)
+}
+
+func isDotenvNameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || isDigit(c) || c == '_'
+}
+
+func isDotenvKeyChar(c byte) bool {
+	return isDotenvNameChar(c) || c == '.' || c == '-'
+}
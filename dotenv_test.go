@@ -0,0 +1,117 @@
+package conf
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestDotenv(t *testing.T) {
+	t.Setenv("CONF_TEST_HOME", "/home/gopher")
+
+	c, err := Load(bytes.NewBufferString(`
+# comment
+export NAME=myapp
+EMPTY=
+UNQUOTED = some value # comment
+HASH=a#b
+SINGLE='literal $NAME \n'
+DOUBLE="tab\there \"quoted\" \$NAME"
+MULTI="line1
+line2"
+EXPANDED=${NAME}-$NAME
+ENV=$CONF_TEST_HOME/bin
+MISSING=${CONF_TEST_MISSING}
+DEFAULT=${CONF_TEST_MISSING:-fallback}
+DOLLAR=100$
+`), Dotenv)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := Config{
+		"NAME":     "myapp",
+		"EMPTY":    "",
+		"UNQUOTED": "some value",
+		"HASH":     "a#b",
+		"SINGLE":   `literal $NAME \n`,
+		"DOUBLE":   "tab\there \"quoted\" $NAME",
+		"MULTI":    "line1\nline2",
+		"EXPANDED": "myapp-myapp",
+		"ENV":      "/home/gopher/bin",
+		"MISSING":  "",
+		"DEFAULT":  "fallback",
+		"DOLLAR":   "100$",
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Fatalf("unexpected configuration: %#v", c)
+	}
+}
+
+func TestNestedDotenv(t *testing.T) {
+	c, err := Load(bytes.NewBufferString(`
+SERVER__ADDRESS=192.168.1.7
+SERVER__PORT=8080
+SERVER__TLS__CERT=cert.pem
+LOG_LEVEL=debug
+`), NestedDotenv("__"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var server struct {
+		Address string
+		Port    int
+		TLS     struct {
+			Cert string
+		}
+	}
+	if err := c.Decode("server", &server); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if server.Address != "192.168.1.7" || server.Port != 8080 || server.TLS.Cert != "cert.pem" {
+		t.Fatalf("unexpected server configuration: %+v", server)
+	}
+	if c["log_level"] != "debug" {
+		t.Fatalf("unexpected configuration: %v", c)
+	}
+
+	_, err = Load(bytes.NewBufferString("A=1\nA__B=2"), NestedDotenv("__"))
+	if serr, ok := err.(*SyntaxError); !ok || serr.Line != 2 || serr.Column != 1 {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = Load(bytes.NewBufferString("A____B=2"), NestedDotenv("__"))
+	if _, ok := err.(*SyntaxError); !ok {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestDotenvErrors(t *testing.T) {
+	invalid := []struct {
+		input  string
+		line   int
+		column int
+	}{
+		{"=foo", 1, 1},
+		{"FOO", 1, 4},
+		{"FOO bar", 1, 5},
+		{"FOO='bar", 1, 5},
+		{"FOO=\"bar", 1, 5},
+		{"FOO=\"b\\qr\"", 1, 7},
+		{"FOO='bar' baz", 1, 11},
+		{"A=1\nFOO=${BAR", 2, 5},
+		{"FOO=${B-R}", 1, 5},
+	}
+
+	for _, test := range invalid {
+		_, err := Load(bytes.NewBufferString(test.input), Dotenv)
+		serr, ok := err.(*SyntaxError)
+		if !ok {
+			t.Fatalf("expected syntax error for %q, got %v", test.input, err)
+		}
+		if serr.Format != "dotenv" || serr.Line != test.line || serr.Column != test.column {
+			t.Fatalf("unexpected error for %q: %v", test.input, err)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package conf
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// EnvOption represents an option which controls how FromEnv maps the
+// environment variables to configuration keys.
+type EnvOption func(*envOptions)
+
+type envOptions struct {
+	sep           string
+	caseSensitive bool
+}
+
+// EnvSeparator sets the separator which splits the names of environment
+// variables into the levels of the configuration hierarchy. The default
+// separator is "_". A separator like "__" allows single underscores within
+// the keys (e.g. APP_SERVER__READ_TIMEOUT maps to "server.read_timeout").
+func EnvSeparator(sep string) EnvOption {
+	return func(o *envOptions) {
+		o.sep = sep
+	}
+}
+
+// EnvCaseSensitive disables the case folding of the configuration keys.
+// By default all keys are converted to lower case.
+func EnvCaseSensitive() EnvOption {
+	return func(o *envOptions) {
+		o.caseSensitive = true
+	}
+}
+
+// FromEnv returns the configuration defined by the environment variables
+// with the given prefix. The prefix and the following separator are removed
+// from the variable names, and the remainder is split at the separator to
+// build the configuration hierarchy. With the default options the variable
+// APP_SERVER_PORT=8080 with the prefix "APP" maps to the key "server.port".
+// If prefix is empty all environment variables are considered.
+//
+// All values are stored as strings. If a variable maps to a key which also
+// has sub-keys (e.g. APP_SERVER and APP_SERVER_PORT), the variable with
+// the sub-keys wins. The returned configuration is usually overlaid onto a
+// configuration loaded from a file (see Config.Overlay).
+//
+// By default all keys are converted to lower case. Since Overlay and Merge
+// compare the keys of different layers case-insensitively, the variable
+// APP_DB_MAXCONNS still overrides the key "db.maxConns" of a file.
+func FromEnv(prefix string, opts ...EnvOption) Config {
+	o := envOptions{sep: "_"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return envConfig(os.Environ(), prefix, o)
+}
+
+func envConfig(environ []string, prefix string, o envOptions) Config {
+	if prefix != "" {
+		prefix += o.sep
+	}
+
+	var vars [][]string // keys followed by the value
+	for _, kv := range environ {
+		i := strings.IndexByte(kv, '=')
+		if i <= 0 || !strings.HasPrefix(kv[:i], prefix) {
+			continue
+		}
+
+		name := kv[len(prefix):i]
+		if !o.caseSensitive {
+			name = strings.ToLower(name)
+		}
+		keys := strings.Split(name, o.sep)
+		if !validKeys(keys) {
+			continue
+		}
+		vars = append(vars, append(keys, kv[i+1:]))
+	}
+
+	// Insert the deeper keys first, so they win over conflicting
+	// variables with less levels.
+	sort.Slice(vars, func(i, j int) bool {
+		if len(vars[i]) != len(vars[j]) {
+			return len(vars[i]) > len(vars[j])
+		}
+		return strings.Join(vars[i], "\x00") < strings.Join(vars[j], "\x00")
+	})
+
+	c := Config{}
+	for _, v := range vars {
+		keys, val := v[:len(v)-1], v[len(v)-1]
+		insert(c, keys, val) // conflicting variables are ignored
+	}
+	return c
+}
+
+func validKeys(keys []string) bool {
+	for _, k := range keys {
+		if k == "" {
+			return false
+		}
+	}
+	return len(keys) != 0
+}
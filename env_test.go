@@ -0,0 +1,98 @@
+package conf
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("CONFTEST_SERVER_PORT", "9090")
+	t.Setenv("CONFTEST_SERVER_TLS_CERT", "cert.pem")
+	t.Setenv("CONFTEST_DEBUG", "true")
+	t.Setenv("CONFTEST_LOG", "ignored")
+	t.Setenv("CONFTEST_LOG_LEVEL", "debug")
+	t.Setenv("CONFTEST__INVALID", "ignored")
+	t.Setenv("CONFTESTX_OTHER", "ignored")
+
+	c := FromEnv("CONFTEST")
+	expected := Config{
+		"debug": "true",
+		"log": map[string]interface{}{
+			"level": "debug",
+		},
+		"server": map[string]interface{}{
+			"port": "9090",
+			"tls": map[string]interface{}{
+				"cert": "cert.pem",
+			},
+		},
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Fatalf("unexpected configuration: %#v", c)
+	}
+}
+
+func TestFromEnvOptions(t *testing.T) {
+	t.Setenv("CONFTEST__Server__Read_Timeout", "5s")
+
+	c := FromEnv("CONFTEST", EnvSeparator("__"), EnvCaseSensitive())
+	expected := Config{
+		"Server": map[string]interface{}{
+			"Read_Timeout": "5s",
+		},
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Fatalf("unexpected configuration: %#v", c)
+	}
+}
+
+func TestConfigOverlay(t *testing.T) {
+	t.Setenv("CONFTEST_SERVER_PORT", "9090")
+
+	base := MustLoad(bytes.NewBufferString(`{
+		"server": { "address": "192.168.1.7", "port": 8080 },
+		"debug": false
+	}`), json.Unmarshal)
+
+	c := base.Overlay(FromEnv("CONFTEST"))
+
+	var server struct {
+		Address string
+		Port    int
+	}
+	if err := c.Decode("server", &server); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if server.Address != "192.168.1.7" || server.Port != 9090 {
+		t.Fatalf("unexpected server configuration: %+v", server)
+	}
+
+	// the base configuration must not be modified
+	if port := base["server"].(map[string]interface{})["port"]; port != 8080.0 {
+		t.Fatalf("unexpected base port: %v", port)
+	}
+}
+
+func TestConfigOverlayCase(t *testing.T) {
+	t.Setenv("CONFTEST_DB_MAXCONNS", "99")
+
+	base := MustLoad(bytes.NewBufferString(`{ "db": { "maxConns": 10 } }`), json.Unmarshal)
+	c := base.Overlay(FromEnv("CONFTEST"))
+
+	db := c["db"].(map[string]interface{})
+	if len(db) != 1 || db["maxConns"] != "99" {
+		t.Fatalf("unexpected db configuration: %v", db)
+	}
+
+	var conf struct {
+		MaxConns int
+	}
+	if err := c.Decode("db", &conf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if conf.MaxConns != 99 {
+		t.Fatalf("unexpected max connections: %d", conf.MaxConns)
+	}
+}
@@ -0,0 +1,191 @@
+package conf
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Flags holds the command-line flags registered by RegisterFlags.
+type Flags struct {
+	values []*flagValue
+}
+
+// RegisterFlags registers a command-line flag in fs for each leaf field of
+// the struct ptr points to. The struct is walked using the same rules as
+// Config.Decode: fields tagged with `config:"-"` and unexported fields are
+// ignored, and the configuration key of a field is taken from its 'config'
+// tag or, if the tag does not specify a key, from its name.
+// Nested structs (and pointers to structs) are walked recursively. Fields
+// of type map, interface, channel and function are ignored.
+//
+// The flag names consist of the prefix and the configuration keys of the
+// field hierarchy, separated by dots, where the names of untagged fields are
+// lower-cased (e.g. "-server.port"). The configuration keys themselves keep
+// the field names, because Config.Decode prefers an exact match of the field
+// name over a case-insensitive one. When the flags are overlaid onto a
+// configuration file, keys which differ only in case are merged (see
+// Merger.Merge).
+//
+// The current field values are shown as defaults and the optional 'usage'
+// tag is used as usage message. Slice fields may be given multiple times on
+// the command line. The struct itself is never modified; after parsing fs,
+// the values of all flags which were set can be retrieved with Flags.Config.
+//
+// Example:
+//   type serverConf struct {
+//       Address string        `usage:"listen address"`
+//       Port    int           `config:"port" usage:"listen port"`
+//       Timeout time.Duration `config:"-"`
+//   }
+//
+//   sv := serverConf{Port: 8080}
+//   flags, err := conf.RegisterFlags(flag.CommandLine, "server", &sv)
+//   // registers the flags -server.address and -server.port
+func RegisterFlags(fs *flag.FlagSet, prefix string, ptr interface{}) (*Flags, error) {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("'%T' is not a pointer to a struct", ptr)
+	}
+
+	var keys []string
+	if prefix != "" {
+		keys = strings.Split(prefix, ".")
+	}
+
+	f := &Flags{}
+	if err := f.register(fs, keys, keys, v.Elem()); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Config returns the values of all registered flags which were set on the
+// command line. The values are stored as strings (or slices of strings for
+// slice fields) under the configuration keys of the flags. The result is
+// usually overlaid onto a configuration loaded from a file (see
+// Config.Overlay).
+func (f *Flags) Config() Config {
+	c := Config{}
+	for _, v := range f.values {
+		if len(v.vals) == 0 {
+			continue
+		}
+
+		var val interface{} = v.vals[len(v.vals)-1]
+		if v.multi {
+			vals := make([]interface{}, len(v.vals))
+			for i, s := range v.vals {
+				vals[i] = s
+			}
+			val = vals
+		}
+		insert(c, v.keys, val) // keys of leaves never conflict
+	}
+	return c
+}
+
+func (f *Flags) register(fs *flag.FlagSet, keys, names []string, v reflect.Value) error {
+	for _, field := range fieldsOf(v) {
+		if field.ignore || !field.value.CanSet() {
+			continue
+		}
+
+		name := field.key
+		if name == "" {
+			name = strings.ToLower(field.name)
+		}
+		fieldKeys := append(keys[:len(keys):len(keys)], field.mapkey())
+		fieldNames := append(names[:len(names):len(names)], name)
+
+		fv := field.value
+		t := fv.Type()
+		if t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct && t.Elem() != timeType {
+			if fv.IsNil() {
+				fv = reflect.New(t.Elem())
+			}
+			fv, t = fv.Elem(), t.Elem()
+		}
+
+		switch t.Kind() {
+		case reflect.Map, reflect.Interface, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+			continue
+		case reflect.Struct:
+			if t != timeType {
+				if err := f.register(fs, fieldKeys, fieldNames, fv); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		name = strings.Join(fieldNames, ".")
+		if fs.Lookup(name) != nil {
+			return fmt.Errorf("flag redefined: %s", name)
+		}
+
+		val := &flagValue{
+			keys: fieldKeys,
+			typ:  t,
+			def:  formatFlagDefault(fv),
+		}
+		if t.Kind() == reflect.Slice {
+			val.multi = true
+			val.typ = t.Elem()
+		}
+		fs.Var(val, name, field.usage)
+		f.values = append(f.values, val)
+	}
+	return nil
+}
+
+type flagValue struct {
+	keys  []string
+	typ   reflect.Type // type of a single value
+	multi bool
+	def   string
+	vals  []string
+}
+
+func (v *flagValue) String() string {
+	if v == nil {
+		return ""
+	}
+	if len(v.vals) == 0 {
+		return v.def
+	}
+	if v.multi {
+		return strings.Join(v.vals, ",")
+	}
+	return v.vals[len(v.vals)-1]
+}
+
+func (v *flagValue) Set(s string) error {
+	if err := decode(reflect.New(v.typ), reflect.ValueOf(s)); err != nil {
+		return err
+	}
+	v.vals = append(v.vals, s)
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool {
+	if v == nil || v.typ == nil {
+		return false
+	}
+	t := v.typ
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return !v.multi && t.Kind() == reflect.Bool
+}
+
+func formatFlagDefault(v reflect.Value) string {
+	if v.IsZero() {
+		return ""
+	}
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,164 @@
+package conf
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type flagsTestConf struct {
+	Name    string `usage:"application name"`
+	Debug   bool
+	Server  flagsTestServer
+	TLS     *flagsTestTLS `config:"tls"`
+	Tags    []string
+	Labels  map[string]string
+	Ignored string `config:"-"`
+	private int
+}
+
+type flagsTestServer struct {
+	Address string        `config:"addr,required"`
+	Port    int           `usage:"listen port"`
+	Timeout time.Duration `usage:"request timeout"`
+}
+
+type flagsTestTLS struct {
+	Cert string
+}
+
+func TestRegisterFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+
+	cfg := flagsTestConf{
+		Server: flagsTestServer{Port: 8080, Timeout: 5 * time.Second},
+	}
+	flags, err := RegisterFlags(fs, "app", &cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) {
+		names = append(names, f.Name)
+	})
+	expectedNames := []string{
+		"app.debug",
+		"app.name",
+		"app.server.addr",
+		"app.server.port",
+		"app.server.timeout",
+		"app.tags",
+		"app.tls.cert",
+	}
+	if !reflect.DeepEqual(names, expectedNames) {
+		t.Fatalf("unexpected flags: %v", names)
+	}
+
+	if f := fs.Lookup("app.server.port"); f.DefValue != "8080" || f.Usage != "listen port" {
+		t.Fatalf("unexpected flag: %+v", f)
+	}
+	if f := fs.Lookup("app.server.timeout"); f.DefValue != "5s" {
+		t.Fatalf("unexpected flag: %+v", f)
+	}
+
+	err = fs.Parse([]string{
+		"-app.debug",
+		"-app.server.port=9090",
+		"-app.tags", "a",
+		"-app.tags", "b",
+		"-app.tls.cert", "cert.pem",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c := flags.Config()
+	expected := Config{
+		"app": map[string]interface{}{
+			"Debug": "true",
+			"Server": map[string]interface{}{
+				"Port": "9090",
+			},
+			"Tags": []interface{}{"a", "b"},
+			"tls": map[string]interface{}{
+				"Cert": "cert.pem",
+			},
+		},
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Fatalf("unexpected configuration: %#v", c)
+	}
+	if cfg.Debug || cfg.Server.Port != 8080 || cfg.TLS != nil {
+		t.Fatalf("struct modified: %+v", cfg)
+	}
+
+	// layer the flags over a file configuration
+	base := MustLoad(bytes.NewBufferString(`{
+		"app": { "Name": "myapp", "Server": { "addr": "192.168.1.7", "Port": 8080 } }
+	}`), json.Unmarshal)
+	if err := base.Overlay(c).Decode("app", &cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Name != "myapp" ||
+		!cfg.Debug ||
+		cfg.Server.Address != "192.168.1.7" ||
+		cfg.Server.Port != 9090 ||
+		cfg.TLS == nil ||
+		cfg.TLS.Cert != "cert.pem" ||
+		len(cfg.Tags) != 2 {
+
+		t.Fatalf("unexpected configuration: %+v", cfg)
+	}
+}
+
+func TestRegisterFlagsFieldNames(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+
+	var cfg struct {
+		Port int
+	}
+	flags, err := RegisterFlags(fs, "srv", &cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := fs.Parse([]string{"-srv.port=9090"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	base := MustLoad(bytes.NewBufferString(`{"srv":{"Port":8080}}`), json.Unmarshal)
+	if err := base.Overlay(flags.Config()).Decode("srv", &cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Port != 9090 {
+		t.Fatalf("unexpected port: %d", cfg.Port)
+	}
+}
+
+func TestRegisterFlagsErrors(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+
+	var cfg flagsTestConf
+	if _, err := RegisterFlags(fs, "", cfg); err == nil {
+		t.Fatalf("expected error, got none")
+	}
+
+	if _, err := RegisterFlags(fs, "", &cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := RegisterFlags(fs, "", &cfg); err == nil || !strings.Contains(err.Error(), "redefined") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := fs.Parse([]string{"-server.port=abc"}); err == nil {
+		t.Fatalf("expected error, got none")
+	}
+}
@@ -0,0 +1,128 @@
+package conf
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// Flatten returns the configuration as a flat map. The keys of the map are
+// the keys of all values which are not maps, built as described for
+// Path.String (e.g. "server.port"). Slices are not flattened, but stored
+// as values, as well as empty maps. The values are not copied.
+//
+// Flatten is the inverse of Unflatten, so Unflatten(c.Flatten()) returns a
+// configuration equal to c, except that all nested maps are converted to
+// map[string]interface{}.
+func (c Config) Flatten() map[string]interface{} {
+	m := make(map[string]interface{})
+	eachChild(reflect.ValueOf(c), func(name string, elem reflect.Value) {
+		flatten(elem, Path{name}, m)
+	})
+	return m
+}
+
+func flatten(val reflect.Value, path Path, m map[string]interface{}) {
+	val = unwrap(val)
+	if val.Kind() != reflect.Map || val.Len() == 0 {
+		m[path.String()] = val.Interface()
+		return
+	}
+	eachChild(val, func(name string, elem reflect.Value) {
+		flatten(elem, appendKeys(path, name), m)
+	})
+}
+
+// Unflatten builds a nested configuration from the flat map m. Each key of
+// m is split into its segments as described for Config.Value, and the value
+// is stored under this hierarchy, e.g. the key "server.port" is stored as
+// the key "port" in the map "server". Indexes in brackets create slices, so
+// "servers[1].port" is stored in the second element of the slice "servers";
+// missing elements are nil. Numbers separated by dots are stored as map
+// keys.
+//
+// An error is returned if a key is invalid, or if two keys conflict because
+// one of them requires a map or slice where the other one stores a value
+// (e.g. "a" and "a.b"). The values of m are not copied.
+func Unflatten(m map[string]interface{}) (Config, error) {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	u := &unflattener{
+		origins: make(map[string]string),
+		leaves:  make(map[string]bool),
+	}
+	c := Config{}
+	for _, key := range keys {
+		segs, err := parseKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := u.set(map[string]interface{}(c), segs, nil, key, m[key]); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+type unflattener struct {
+	origins map[string]string // key which created the value at each path
+	leaves  map[string]bool   // paths of the values stored by a key
+}
+
+// set stores v under the hierarchy given by segs in node, which is located
+// at the given path, and returns the updated node. The key is the flat key
+// of v.
+func (u *unflattener) set(node interface{}, segs []keySegment, path Path, key string, v interface{}) (interface{}, error) {
+	pathKey := path.String()
+	other, exists := u.origins[pathKey]
+	switch {
+	case len(segs) == 0 && exists, u.leaves[pathKey]:
+		return nil, fmt.Errorf("key '%s' conflicts with key '%s'", key, other)
+	case len(segs) == 0:
+		u.origins[pathKey] = key
+		u.leaves[pathKey] = true
+		return v, nil
+	case !exists && path != nil:
+		u.origins[pathKey] = key
+	}
+
+	seg := segs[0]
+	if seg.index {
+		idx, _ := strconv.Atoi(seg.name)
+		if idx < 0 {
+			return nil, fmt.Errorf("invalid key '%s': negative index '%s'", key, seg.name)
+		}
+		s, ok := node.([]interface{})
+		if node != nil && !ok {
+			return nil, fmt.Errorf("key '%s' conflicts with key '%s'", key, other)
+		}
+		for len(s) <= idx {
+			s = append(s, nil)
+		}
+		elem, err := u.set(s[idx], segs[1:], appendKeys(path, seg.name), key, v)
+		if err != nil {
+			return nil, err
+		}
+		s[idx] = elem
+		return s, nil
+	}
+
+	mm, ok := node.(map[string]interface{})
+	if node != nil && !ok {
+		return nil, fmt.Errorf("key '%s' conflicts with key '%s'", key, other)
+	}
+	if mm == nil {
+		mm = make(map[string]interface{})
+	}
+	elem, err := u.set(mm[seg.name], segs[1:], appendKeys(path, seg.name), key, v)
+	if err != nil {
+		return nil, err
+	}
+	mm[seg.name] = elem
+	return mm, nil
+}
@@ -0,0 +1,153 @@
+package conf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConfigFlatten(t *testing.T) {
+	c := Config{
+		"name": "app",
+		"server": map[string]interface{}{
+			"port": 8080,
+			"tls": map[string]interface{}{
+				"enabled": true,
+			},
+		},
+		"hosts": map[interface{}]interface{}{
+			"example.com": 443,
+		},
+		"tags":  []interface{}{"a", "b"},
+		"empty": map[string]interface{}{},
+		"nil":   nil,
+	}
+
+	expected := map[string]interface{}{
+		"name":                "app",
+		"server.port":         8080,
+		"server.tls.enabled":  true,
+		`hosts."example.com"`: 443,
+		"tags":                []interface{}{"a", "b"},
+		"empty":               map[string]interface{}{},
+		"nil":                 nil,
+	}
+	flat := c.Flatten()
+	if !reflect.DeepEqual(flat, expected) {
+		t.Fatalf("unexpected flat map: %v", flat)
+	}
+
+	for key, v := range flat {
+		val, err := c.Value(key)
+		if err != nil {
+			t.Fatalf("unexpected error for key %s: %v", key, err)
+		}
+		if !reflect.DeepEqual((*reflect.Value)(val).Interface(), v) {
+			t.Fatalf("unexpected value for key %s: %v", key, v)
+		}
+	}
+
+	u, err := Unflatten(flat)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c["hosts"] = map[string]interface{}{"example.com": 443}
+	if !reflect.DeepEqual(u, c) {
+		t.Fatalf("unexpected unflattened configuration: %v", u)
+	}
+}
+
+func TestUnflatten(t *testing.T) {
+	m := map[string]interface{}{
+		"server.port":          "8080",
+		"server.host":          "localhost",
+		`labels."app.io/name"`: "web",
+		"servers[1].port":      81,
+		"servers[0].port":      80,
+		"matrix[0][1]":         1,
+		"codes.404":            "not found",
+	}
+
+	c, err := Unflatten(m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := Config{
+		"server": map[string]interface{}{
+			"port": "8080",
+			"host": "localhost",
+		},
+		"labels": map[string]interface{}{
+			"app.io/name": "web",
+		},
+		"servers": []interface{}{
+			map[string]interface{}{"port": 80},
+			map[string]interface{}{"port": 81},
+		},
+		"matrix": []interface{}{
+			[]interface{}{nil, 1},
+		},
+		"codes": map[string]interface{}{
+			"404": "not found",
+		},
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Fatalf("unexpected configuration: %v", c)
+	}
+
+	for key := range m {
+		if _, err := c.Value(key); err != nil {
+			t.Fatalf("unexpected error for key %s: %v", key, err)
+		}
+	}
+}
+
+func TestUnflattenErrors(t *testing.T) {
+	tests := []struct {
+		m      map[string]interface{}
+		errMsg string
+	}{
+		{
+			m:      map[string]interface{}{"a": 1, "a.b": 2},
+			errMsg: "key 'a.b' conflicts with key 'a'",
+		},
+		{
+			m:      map[string]interface{}{"a": nil, "a.b.c": 2},
+			errMsg: "key 'a.b.c' conflicts with key 'a'",
+		},
+		{
+			m:      map[string]interface{}{"a.b": 1, "a.b.c": 2, "a.d": 3},
+			errMsg: "key 'a.b.c' conflicts with key 'a.b'",
+		},
+		{
+			m:      map[string]interface{}{"a.b.c": 1, "a.b": 2},
+			errMsg: "key 'a.b.c' conflicts with key 'a.b'",
+		},
+		{
+			m:      map[string]interface{}{`"a".b`: 1, "a.b": 2},
+			errMsg: "key 'a.b' conflicts with key '\"a\".b'",
+		},
+		{
+			m:      map[string]interface{}{"a.0": 1, "a[1]": 2},
+			errMsg: "key 'a[1]' conflicts with key 'a.0'",
+		},
+		{
+			m:      map[string]interface{}{"a[-1]": 1},
+			errMsg: "invalid key 'a[-1]': negative index '-1'",
+		},
+		{
+			m:      map[string]interface{}{"a[x]": 1},
+			errMsg: "invalid key 'a[x]': invalid index 'x'",
+		},
+	}
+
+	for _, test := range tests {
+		_, err := Unflatten(test.m)
+		if err == nil {
+			t.Fatalf("expected error for %v, got none", test.m)
+		}
+		if err.Error() != test.errMsg {
+			t.Fatalf("unexpected error for %v: %v", test.m, err)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// parseFunc parses the given data like an Unmarshaler. Additionally it
+// stores the line of each key in lines, if lines is not nil.
+type parseFunc func(data string, lines map[string]int) (map[string]interface{}, error)
+
+var formats = struct {
+	sync.RWMutex
+	unmarshalers map[string]Unmarshaler
+	parsers      map[string]parseFunc // built-in parsers which know key lines
+}{
+	unmarshalers: map[string]Unmarshaler{
+		".json":       json.Unmarshal,
+		".toml":       TOML,
+		".ini":        INI,
+		".env":        Dotenv,
+		".properties": Properties,
+	},
+	parsers: map[string]parseFunc{
+		".toml": parseTOML,
+		".ini":  parseINI,
+		".env": func(data string, lines map[string]int) (map[string]interface{}, error) {
+			return parseDotenv(data, "", lines)
+		},
+		".properties": parseProperties,
+	},
+}
+
+// RegisterFormat registers unmarshal as the parser for all configuration
+// files with the extension ext (e.g. ".yaml"). The leading dot is optional
+// and the extension is matched case-insensitively. A previously registered
+// parser for the same extension will be replaced. If unmarshal is nil the
+// extension will be unregistered.
+//
+// JSON (".json"), TOML (".toml"), INI (".ini"), dotenv (".env") and Java
+// properties (".properties") files are supported out of the box.
+func RegisterFormat(ext string, unmarshal Unmarshaler) {
+	ext = normalizeExt(ext)
+
+	formats.Lock()
+	defer formats.Unlock()
+
+	delete(formats.parsers, ext)
+	if unmarshal == nil {
+		delete(formats.unmarshalers, ext)
+	} else {
+		formats.unmarshalers[ext] = unmarshal
+	}
+}
+
+// LoadFile loads the configuration from the file with the given path. The
+// parser is chosen by the file's extension (see RegisterFormat). If no
+// parser is registered for the extension an error is returned. Include
+// directives within the file are resolved (see IncludeKey).
+func LoadFile(path string) (Config, error) {
+	return loadFile(fileSystem{}, path, nil)
+}
+
+// loadFile loads the configuration file with the given path from fsys and
+// resolves its includes. The stack holds the files which include the file.
+func loadFile(fsys fileSystem, path string, stack []string) (Config, error) {
+	c, _, err := fsys.load(path)
+	if err != nil {
+		return nil, err
+	}
+	if err = resolveIncludes(fsys, c, path, stack); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// MustLoadFile ensures the loading of the configuration from the file with
+// the given path. This function calls LoadFile and panics on error.
+func MustLoadFile(path string) Config {
+	c, err := LoadFile(path)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func lookupFormat(ext string) (Unmarshaler, error) {
+	ext = normalizeExt(ext)
+
+	formats.RLock()
+	unmarshal := formats.unmarshalers[ext]
+	formats.RUnlock()
+
+	if unmarshal == nil {
+		if ext == "" {
+			return nil, fmt.Errorf("unknown configuration format: missing file extension")
+		}
+		return nil, fmt.Errorf("unknown configuration format: %s", ext)
+	}
+	return unmarshal, nil
+}
+
+// lookupParser returns the built-in parser for the given extension, or nil
+// if the extension is not handled by a built-in parser.
+func lookupParser(ext string) parseFunc {
+	formats.RLock()
+	defer formats.RUnlock()
+	return formats.parsers[normalizeExt(ext)]
+}
+
+func normalizeExt(ext string) string {
+	ext = strings.ToLower(ext)
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+// SyntaxError describes a syntax error found by one of the built-in
+// configuration parsers.
+type SyntaxError struct {
+	Format string // name of the configuration format (e.g. "toml")
+	Line   int    // line of the error (1-based)
+	Column int    // column of the error (1-based, counted in characters)
+	Msg    string // description of the error
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s: line %d, column %d: %s", e.Format, e.Line, e.Column, e.Msg)
+}
+
+// syntaxError creates a syntax error for the position pos in data.
+func syntaxError(format string, data string, pos int, msg string, args ...interface{}) *SyntaxError {
+	line, col := position(data, pos)
+	return &SyntaxError{
+		Format: format,
+		Line:   line,
+		Column: col,
+		Msg:    fmt.Sprintf(msg, args...),
+	}
+}
+
+// storeMap stores the parsed configuration m in value, which has to be
+// a pointer. It is used by the built-in unmarshalers to fill the Config
+// passed by Load.
+func storeMap(m map[string]interface{}, value interface{}) error {
+	output := reflect.ValueOf(value)
+	if output.Kind() != reflect.Ptr || output.IsNil() {
+		return fmt.Errorf("'%T' is not a pointer type", value)
+	}
+	return decode(output, reflect.ValueOf(m))
+}
+
+// position returns the line and column (both 1-based) of the byte offset
+// pos in data.
+func position(data string, pos int) (int, int) {
+	line, col := 1, 1
+	for _, c := range data[:pos] {
+		if c == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	return line, col
+}
+
+// lineCounter computes the lines of byte offsets in data. Since parsers
+// ask for increasing offsets, it continues counting from the previous offset
+// instead of scanning data from the start for every key.
+type lineCounter struct {
+	data string
+	pos  int
+	line int
+}
+
+// lineAt returns the 1-based line of the byte offset pos.
+func (lc *lineCounter) lineAt(pos int) int {
+	if lc.line == 0 || pos < lc.pos {
+		lc.pos, lc.line = 0, 1
+	}
+	lc.line += strings.Count(lc.data[lc.pos:pos], "\n")
+	lc.pos = pos
+	return lc.line
+}
+
+// recordLine stores the line of key in lines, unless lines is nil.
+func recordLine(lines map[string]int, key string, line int) {
+	if lines != nil {
+		lines[key] = line
+	}
+}
@@ -0,0 +1,103 @@
+package conf
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+
+	path := writeTestFile(t, dir, "config.JSON", `{ "foo": { "bar": 7 } }`)
+	c, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var i int
+	if err = c.Decode("foo.bar", &i); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if i != 7 {
+		t.Fatalf("unexpected value: %v", i)
+	}
+
+	// unknown extension
+	path = writeTestFile(t, dir, "config.unknown", `foo`)
+	_, err = LoadFile(path)
+	if err == nil || err.Error() != "unknown configuration format: .unknown" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// missing extension
+	path = writeTestFile(t, dir, "config", `foo`)
+	_, err = LoadFile(path)
+	if err == nil {
+		t.Fatalf("expected error, got none")
+	}
+
+	// missing file
+	_, err = LoadFile(filepath.Join(dir, "missing.json"))
+	if !os.IsNotExist(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRegisterFormat(t *testing.T) {
+	formatErr := errors.New("format error")
+	RegisterFormat("test", func(data []byte, value interface{}) error {
+		if string(data) != "foo" {
+			return formatErr
+		}
+		return json.Unmarshal([]byte(`{ "foo": "bar" }`), value)
+	})
+	defer RegisterFormat(".test", nil)
+
+	dir := t.TempDir()
+
+	path := writeTestFile(t, dir, "config.test", `foo`)
+	c, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c["foo"] != "bar" {
+		t.Fatalf("unexpected configuration: %v", c)
+	}
+
+	path = writeTestFile(t, dir, "invalid.test", `bar`)
+	_, err = LoadFile(path)
+	if err != formatErr {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	RegisterFormat(".TEST", nil)
+	_, err = LoadFile(path)
+	if err == nil {
+		t.Fatalf("expected error, got none")
+	}
+}
+
+func TestMustLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.json")
+	_, b := panicked(func() {
+		MustLoadFile(path)
+	})
+	if !b {
+		t.Fatalf("expected panic, got none")
+	}
+}
+
+func writeTestFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return path
+}
@@ -0,0 +1,141 @@
+package conf
+
+import (
+	"bytes"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LoadFS loads the configuration from the file with the given name in
+// fsys, e.g. an embed.FS holding default configurations. The name has to
+// be a slash-separated path as described for fs.ValidPath. Apart from that
+// the file is loaded like LoadFile does. Include directives are resolved
+// within fsys; absolute include paths are relative to the root of fsys.
+func LoadFS(fsys fs.FS, name string) (Config, error) {
+	return loadFile(fileSystem{fsys}, name, nil)
+}
+
+// LoadGlob loads all configuration files matching pattern (see
+// filepath.Glob) and merges them in lexical order of their paths with the
+// default options (see Merge). Each file is loaded like LoadFile does.
+// Errors of specific files are reported as *FileError.
+func LoadGlob(pattern string) (Config, error) {
+	return loadGlob(fileSystem{}, pattern)
+}
+
+// LoadGlobFS is like LoadGlob, but loads the files from fsys. The pattern
+// is matched as described for fs.Glob.
+func LoadGlobFS(fsys fs.FS, pattern string) (Config, error) {
+	return loadGlob(fileSystem{fsys}, pattern)
+}
+
+func loadGlob(fsys fileSystem, pattern string) (Config, error) {
+	names, err := fsys.glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	layers := make([]Config, 0, len(names))
+	for _, name := range names {
+		c, err := loadFile(fsys, name, nil)
+		if err != nil {
+			return nil, &FileError{Path: name, Err: err}
+		}
+		layers = append(layers, c)
+	}
+	return Merge(layers...), nil
+}
+
+// fileSystem provides access to configuration files, either in the OS
+// file system (if fsys is nil) or in fsys.
+type fileSystem struct {
+	fsys fs.FS
+}
+
+func (f fileSystem) readFile(name string) ([]byte, error) {
+	if f.fsys == nil {
+		return ioutil.ReadFile(name)
+	}
+	return fs.ReadFile(f.fsys, name)
+}
+
+func (f fileSystem) readDir(name string) ([]fs.DirEntry, error) {
+	if f.fsys == nil {
+		return os.ReadDir(name)
+	}
+	return fs.ReadDir(f.fsys, name)
+}
+
+// glob returns the names of all files matching pattern in lexical order.
+func (f fileSystem) glob(pattern string) ([]string, error) {
+	if f.fsys == nil {
+		return filepath.Glob(pattern)
+	}
+	return fs.Glob(f.fsys, pattern)
+}
+
+// abs returns the canonical path of the file with the given name, which
+// identifies the file when detecting include cycles.
+func (f fileSystem) abs(name string) (string, error) {
+	if f.fsys == nil {
+		return filepath.Abs(name)
+	}
+	return path.Clean(name), nil
+}
+
+// include returns the path of the file name which is included by a file
+// in the directory dir.
+func (f fileSystem) include(dir, name string) string {
+	switch {
+	case f.fsys != nil && path.IsAbs(name):
+		return path.Clean(strings.TrimLeft(name, "/"))
+	case f.fsys != nil:
+		return path.Join(dir, name)
+	case filepath.IsAbs(name):
+		return name
+	}
+	return filepath.Join(dir, name)
+}
+
+func (f fileSystem) dir(name string) string {
+	if f.fsys == nil {
+		return filepath.Dir(name)
+	}
+	return path.Dir(name)
+}
+
+func (f fileSystem) join(dir, name string) string {
+	if f.fsys == nil {
+		return filepath.Join(dir, name)
+	}
+	return path.Join(dir, name)
+}
+
+func (f fileSystem) ext(name string) string {
+	if f.fsys == nil {
+		return filepath.Ext(name)
+	}
+	return path.Ext(name)
+}
+
+// load loads the configuration file with the given name and returns the
+// configuration along with the raw data. Includes are not resolved.
+func (f fileSystem) load(name string) (Config, []byte, error) {
+	unmarshal, err := lookupFormat(f.ext(name))
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := f.readFile(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	c, err := Load(bytes.NewReader(data), unmarshal)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, data, nil
+}
@@ -0,0 +1,147 @@
+package conf
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config/app.toml": {Data: []byte(`
+"$include" = ["common.json", "/shared/tls.ini"]
+name = "app"
+`)},
+		"config/common.json": {Data: []byte(`{ "name": "common", "port": 8080 }`)},
+		"shared/tls.ini":     {Data: []byte("[tls]\nenabled = true\n")},
+	}
+
+	c, err := LoadFS(fsys, "config/app.toml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c["name"] != "app" || c["port"] != 8080.0 {
+		t.Fatalf("unexpected configuration: %v", c)
+	}
+	if v, err := c.Value("tls.enabled"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if s, _ := v.String(); s != "true" {
+		t.Fatalf("unexpected tls.enabled: %s", s)
+	}
+}
+
+func TestLoadFSErrors(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.json":       {Data: []byte(`{ "$include": "b.json" }`)},
+		"b.json":       {Data: []byte(`{ "$include": "a.json" }`)},
+		"escape.json":  {Data: []byte(`{ "$include": "../outside.json" }`)},
+		"invalid.json": {Data: []byte(`{`)},
+		"config.xyz":   {Data: []byte(``)},
+	}
+
+	tests := []struct {
+		name   string
+		errMsg string
+	}{
+		{name: "missing.json", errMsg: "file does not exist"},
+		{name: "a.json", errMsg: "include cycle: a.json -> b.json -> a.json"},
+		{name: "escape.json", errMsg: "cannot include '../outside.json'"},
+		{name: "invalid.json", errMsg: "unexpected end of JSON input"},
+		{name: "config.xyz", errMsg: "unknown configuration format: .xyz"},
+	}
+	for _, test := range tests {
+		_, err := LoadFS(fsys, test.name)
+		if err == nil {
+			t.Fatalf("expected error for %s, got none", test.name)
+		}
+		if !strings.Contains(err.Error(), test.errMsg) {
+			t.Fatalf("unexpected error for %s: %v", test.name, err)
+		}
+	}
+}
+
+func TestLoadDirFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"conf.d/10-base.json":     {Data: []byte(`{ "name": "base", "port": 80 }`)},
+		"conf.d/20-override.toml": {Data: []byte(`port = 8080`)},
+		"conf.d/README.md":        {Data: []byte(`ignored`)},
+		"conf.d/sub/30-sub.json":  {Data: []byte(`{ "name": "ignored" }`)},
+	}
+
+	c, err := LoadDirFS(fsys, "conf.d", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c["name"] != "base" || c["port"] != int64(8080) {
+		t.Fatalf("unexpected configuration: %v", c)
+	}
+}
+
+func TestLoadGlob(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "a.json", `{ "a": 1, "b": 1 }`)
+	writeTestFile(t, dir, "b.json", `{ "b": 2 }`)
+	writeTestFile(t, dir, "c.toml", `c = 3`)
+
+	c, err := LoadGlob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(c) != 2 || c["a"] != 1.0 || c["b"] != 2.0 {
+		t.Fatalf("unexpected configuration: %v", c)
+	}
+}
+
+func TestLoadGlobFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"defaults/a.json":  {Data: []byte(`{ "a": 1, "b": 1 }`)},
+		"defaults/b.json":  {Data: []byte(`{ "b": 2 }`)},
+		"defaults/c.json":  {Data: []byte(`{`)},
+		"defaults/d.toml":  {Data: []byte(`d = 4`)},
+		"overrides/e.json": {Data: []byte(`{ "e": 5 }`)},
+	}
+
+	c, err := LoadGlobFS(fsys, "*/[ab].json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(c) != 2 || c["a"] != 1.0 || c["b"] != 2.0 {
+		t.Fatalf("unexpected configuration: %v", c)
+	}
+
+	_, err = LoadGlobFS(fsys, "defaults/*.json")
+	var fileErr *FileError
+	if !errors.As(err, &fileErr) || fileErr.Path != "defaults/c.json" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := LoadGlobFS(fsys, "["); !errors.Is(err, path.ErrBadPattern) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestLayersAddFileFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"defaults.toml": {Data: []byte("[server]\nport = 80\n")},
+	}
+
+	var l Layers
+	if err := l.AddFileFS(fsys, "defaults.toml"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	o, err := l.Origin("server.port")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if o.String() != "defaults.toml:2" {
+		t.Fatalf("unexpected origin: %s", o)
+	}
+
+	if err := l.AddFileFS(fsys, "missing.toml"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+package conf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// contentTypes maps media types to the extensions of their formats (see
+// RegisterFormat). Media types which are not listed here are mapped by
+// their subtype or suffix, e.g. "application/yaml" and "application/x-yaml"
+// are mapped to ".yaml" and "application/vnd.foo+json" to ".json".
+var contentTypes = map[string]string{
+	"text/x-java-properties": ".properties",
+	"text/x-dotenv":          ".env",
+}
+
+// WatchURL loads the configuration from the given HTTP(S) URL and watches
+// it for changes. If unmarshal is nil, the parser is chosen by the
+// Content-Type of the response, or by the extension of the URL's path if
+// the content type is unknown (see RegisterFormat). If the initial request
+// fails an error is returned.
+//
+// The URL is requested in the watch interval. If the server sends an ETag
+// header, subsequent requests are conditional (If-None-Match), so unchanged
+// documents are not transferred again. After a failed request the time until
+// the next request is doubled up to a maximum (see WatchBackoff). Besides
+// that the Watcher behaves as described for Watch: fn is called with the
+// previous and the new configuration whenever the document changed and was
+// parsed successfully.
+func WatchURL(url string, unmarshal Unmarshaler, fn func(old, new Config), opts ...WatchOption) (*Watcher, error) {
+	o := newWatchOptions(opts)
+	p := newHTTPPoller(url, unmarshal, o)
+	c, err := p.fetch(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return startWatcher(c, p.poll, fn, o), nil
+}
+
+func newHTTPPoller(url string, unmarshal Unmarshaler, opts watchOptions) *httpPoller {
+	if opts.client == nil {
+		opts.client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &httpPoller{
+		url:       url,
+		unmarshal: unmarshal,
+		opts:      opts,
+	}
+}
+
+// httpPoller polls a configuration document via HTTP.
+type httpPoller struct {
+	url       string
+	unmarshal Unmarshaler
+	opts      watchOptions
+
+	etag     string
+	data     []byte
+	failures int // number of consecutive failures
+}
+
+func (p *httpPoller) poll(ctx context.Context) (Config, time.Duration, error) {
+	c, err := p.fetch(ctx)
+	if err != nil {
+		p.failures++
+		return nil, p.backoff(), err
+	}
+	p.failures = 0
+	return c, p.opts.interval, nil
+}
+
+// backoff returns the time until the next request after a failure.
+func (p *httpPoller) backoff() time.Duration {
+	d := p.opts.interval
+	for i := 0; i < p.failures && d < p.opts.backoff; i++ {
+		d *= 2
+	}
+	if d > p.opts.backoff {
+		d = p.opts.backoff
+	}
+	return d
+}
+
+// fetch requests the document and returns its configuration. If the
+// document was not modified since the last request, nil is returned.
+func (p *httpPoller) fetch(ctx context.Context) (Config, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.etag != "" {
+		req.Header.Set("If-None-Match", p.etag)
+	}
+
+	resp, err := p.opts.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unexpected status '%s' from '%s'", resp.Status, p.url)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if p.data != nil && bytes.Equal(data, p.data) {
+		p.etag = resp.Header.Get("ETag")
+		return nil, nil
+	}
+
+	unmarshal := p.unmarshal
+	if unmarshal == nil {
+		if unmarshal, err = p.lookupFormat(resp.Header.Get("Content-Type")); err != nil {
+			return nil, err
+		}
+	}
+	c, err := Load(bytes.NewReader(data), unmarshal)
+	if err != nil {
+		return nil, err
+	}
+
+	p.etag = resp.Header.Get("ETag")
+	p.data = data
+	return c, nil
+}
+
+// lookupFormat returns the unmarshaler for the given content type. If the
+// content type is unknown, the extension of the URL's path is used.
+func (p *httpPoller) lookupFormat(contentType string) (Unmarshaler, error) {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		for _, ext := range mediaTypeExts(mediaType) {
+			if unmarshal, err := lookupFormat(ext); err == nil {
+				return unmarshal, nil
+			}
+		}
+	}
+
+	u, err := url.Parse(p.url)
+	if err != nil {
+		return nil, err
+	}
+	if ext := path.Ext(u.Path); ext != "" {
+		return lookupFormat(ext)
+	}
+	return nil, fmt.Errorf("unknown content type '%s' from '%s'", contentType, p.url)
+}
+
+// mediaTypeExts returns the candidate extensions for the given media type.
+func mediaTypeExts(mediaType string) []string {
+	if ext, has := contentTypes[mediaType]; has {
+		return []string{ext}
+	}
+
+	var exts []string
+	i := strings.IndexByte(mediaType, '/')
+	if i < 0 {
+		return nil
+	}
+	subtype := mediaType[i+1:]
+	if j := strings.LastIndexByte(subtype, '+'); j >= 0 {
+		exts = append(exts, "."+subtype[j+1:])
+		subtype = subtype[:j]
+	}
+	return append(exts, "."+strings.TrimPrefix(subtype, "x-"))
+}
@@ -0,0 +1,224 @@
+package conf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testDocument is a configuration document served by a test server.
+type testDocument struct {
+	mu          sync.Mutex
+	contentType string
+	body        string
+	version     int
+	fail        bool
+	requests    int
+	notModified int
+}
+
+func (d *testDocument) set(body string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.body = body
+	d.version++
+}
+
+func (d *testDocument) setFail(fail bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.fail = fail
+}
+
+func (d *testDocument) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.requests++
+	if d.fail {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	etag := `"` + strconv.Itoa(d.version) + `"`
+	if r.Header.Get("If-None-Match") == etag {
+		d.notModified++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Type", d.contentType)
+	w.Write([]byte(d.body))
+}
+
+func TestWatchURL(t *testing.T) {
+	doc := &testDocument{
+		contentType: "application/toml; charset=utf-8",
+		body:        "version = 1",
+	}
+	srv := httptest.NewServer(doc)
+	defer srv.Close()
+
+	events := make(chan watchEvent, 10)
+	errs := make(chan error, 10)
+	w, err := WatchURL(srv.URL+"/config", nil, func(old, new Config) {
+		events <- watchEvent{old: old, new: new}
+	}, WatchInterval(5*time.Millisecond), WatchBackoff(20*time.Millisecond), WatchErrors(func(err error) {
+		errs <- err
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer w.Close()
+
+	if v := w.Config()["version"]; v != int64(1) {
+		t.Fatalf("unexpected initial version: %v", v)
+	}
+
+	doc.set("version = 2")
+	ev := nextWatchEvent(t, events)
+	if ev.old["version"] != int64(1) || ev.new["version"] != int64(2) {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+
+	// failures keep the previous configuration
+	doc.setFail(true)
+	select {
+	case <-errs:
+	case ev := <-events:
+		t.Fatalf("unexpected event: %+v", ev)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for error")
+	}
+	if v := w.Config()["version"]; v != int64(2) {
+		t.Fatalf("unexpected version: %v", v)
+	}
+
+	doc.set("version = 3")
+	doc.setFail(false)
+	ev = nextWatchEvent(t, events)
+	if ev.old["version"] != int64(2) || ev.new["version"] != int64(3) {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+
+	// unchanged documents are not transferred again
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		doc.mu.Lock()
+		notModified := doc.notModified
+		doc.mu.Unlock()
+		if notModified > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for conditional request")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected event: %+v", ev)
+	default:
+	}
+}
+
+func TestWatchURLFormat(t *testing.T) {
+	tests := []struct {
+		path        string
+		contentType string
+		body        string
+	}{
+		{path: "/", contentType: "application/json", body: `{"key": "value"}`},
+		{path: "/", contentType: "application/vnd.app+json", body: `{"key": "value"}`},
+		{path: "/", contentType: "text/x-java-properties", body: "key=value"},
+		{path: "/config.ini", contentType: "text/plain", body: "key = value"},
+		{path: "/config.toml", contentType: "", body: `key = "value"`},
+	}
+
+	for _, test := range tests {
+		srv := httptest.NewServer(&testDocument{contentType: test.contentType, body: test.body})
+		w, err := WatchURL(srv.URL+test.path, nil, nil)
+		if err != nil {
+			srv.Close()
+			t.Fatalf("unexpected error for %s: %v", test.contentType, err)
+		}
+		v := w.Config()["key"]
+		w.Close()
+		srv.Close()
+		if v != "value" {
+			t.Fatalf("unexpected value for %s: %v", test.contentType, v)
+		}
+	}
+}
+
+func TestWatchURLErrors(t *testing.T) {
+	doc := &testDocument{contentType: "text/plain", body: "key=value", fail: true}
+	srv := httptest.NewServer(doc)
+	defer srv.Close()
+
+	if _, err := WatchURL(srv.URL, nil, nil); err == nil {
+		t.Fatalf("expected error, got none")
+	}
+
+	doc.setFail(false)
+	if _, err := WatchURL(srv.URL, nil, nil); err == nil {
+		t.Fatalf("expected error, got none")
+	}
+}
+
+func TestWatchURLClose(t *testing.T) {
+	blocked := make(chan struct{}, 1)
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests > 1 {
+			// block until the request is cancelled
+			blocked <- struct{}{}
+			<-r.Context().Done()
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"version": 1}`))
+	}))
+	defer srv.Close()
+
+	w, err := WatchURL(srv.URL, nil, nil, WatchInterval(5*time.Millisecond))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case <-blocked:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for request")
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		w.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("close blocked by pending request")
+	}
+}
+
+func TestHTTPPollerBackoff(t *testing.T) {
+	p := &httpPoller{
+		opts: watchOptions{
+			interval: time.Second,
+			backoff:  10 * time.Second,
+		},
+	}
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for failures, exp := range expected {
+		p.failures = failures
+		if d := p.backoff(); d != exp {
+			t.Fatalf("unexpected backoff after %d failures: %v", failures, d)
+		}
+	}
+}
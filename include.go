@@ -0,0 +1,134 @@
+package conf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// IncludeKey is the reserved configuration key for include directives.
+// Its value is the path of another configuration file, or a list of paths,
+// which are loaded and merged into the map containing the directive. Relative
+// paths are resolved relative to the directory of the including file.
+//
+// The included files are merged in the order they are listed (see Merge),
+// and the other values of the map containing the directive take precedence
+// over the included values. Include directives may occur at any level of the
+// configuration, e.g. to include a shared TLS configuration:
+//   {
+//       "$include": "common/defaults.json",
+//       "server": {
+//           "$include": ["common/tls.json"],
+//           "port": 8443
+//       }
+//   }
+//
+// Included files may include further files up to a depth of
+// MaxIncludeDepth. Cyclic includes are reported as error. Include
+// directives are resolved by all functions which load files by path (e.g.
+// LoadFile, LoadFS, Watch or Layers.AddFile), but not by Load.
+const IncludeKey = "$include"
+
+// MaxIncludeDepth is the maximum nesting depth of included files.
+const MaxIncludeDepth = 16
+
+// resolveIncludes resolves all include directives in c, which was loaded
+// from the file with the given path. The stack holds the files which include
+// the file.
+func resolveIncludes(fsys fileSystem, c Config, path string, stack []string) error {
+	abs, err := fsys.abs(path)
+	if err != nil {
+		return err
+	}
+	for _, p := range stack {
+		if p == abs {
+			return fmt.Errorf("include cycle: %s -> %s", strings.Join(stack, " -> "), abs)
+		}
+	}
+	stack = append(stack[:len(stack):len(stack)], abs)
+
+	_, err = resolveValueIncludes(fsys, c, fsys.dir(abs), stack)
+	return err
+}
+
+// resolveValueIncludes resolves the include directives in v and returns the
+// resolved value. Maps with string keys and slices are modified in place,
+// other maps (see asMap) are replaced by a converted copy.
+func resolveValueIncludes(fsys fileSystem, v interface{}, dir string, stack []string) (interface{}, error) {
+	if s, ok := v.([]interface{}); ok {
+		for i, elem := range s {
+			res, err := resolveValueIncludes(fsys, elem, dir, stack)
+			if err != nil {
+				return nil, err
+			}
+			s[i] = res
+		}
+		return s, nil
+	}
+
+	m, ok := asMap(v)
+	if !ok {
+		return v, nil
+	}
+	for k, elem := range m {
+		if k == IncludeKey {
+			continue
+		}
+		res, err := resolveValueIncludes(fsys, elem, dir, stack)
+		if err != nil {
+			return nil, err
+		}
+		m[k] = res
+	}
+
+	directive, has := m[IncludeKey]
+	if !has {
+		return m, nil
+	}
+	paths, err := includePaths(directive)
+	if err != nil {
+		return nil, err
+	}
+	if len(stack) > MaxIncludeDepth {
+		return nil, fmt.Errorf("maximum include depth of %d exceeded in '%s'", MaxIncludeDepth, stack[len(stack)-1])
+	}
+
+	layers := make([]Config, 0, len(paths)+1)
+	for _, path := range paths {
+		path = fsys.include(dir, path)
+		c, err := loadFile(fsys, path, stack)
+		if err != nil {
+			return nil, fmt.Errorf("cannot include '%s' in '%s': %v", path, stack[len(stack)-1], err)
+		}
+		layers = append(layers, c)
+	}
+
+	delete(m, IncludeKey)
+	merged := Merge(append(layers, m)...)
+	for k := range m {
+		delete(m, k)
+	}
+	for k, elem := range merged {
+		m[k] = elem
+	}
+	return m, nil
+}
+
+func includePaths(directive interface{}) ([]string, error) {
+	switch d := directive.(type) {
+	case string:
+		return []string{d}, nil
+	case []string:
+		return d, nil
+	case []interface{}:
+		paths := make([]string, len(d))
+		for i, p := range d {
+			s, ok := p.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid include directive: '%v'", directive)
+			}
+			paths[i] = s
+		}
+		return paths, nil
+	}
+	return nil, fmt.Errorf("invalid include directive: '%v'", directive)
+}
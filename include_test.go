@@ -0,0 +1,126 @@
+package conf
+
+import (
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLoadFileIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "common/defaults.toml", `
+debug = false
+"$include" = "log.json"
+[server]
+port = 80
+`)
+	writeTestFile(t, dir, "common/log.json", `{ "log": { "level": "info" } }`)
+	writeTestFile(t, dir, "common/tls.json", `{ "cert": "cert.pem", "key": "default.pem" }`)
+	path := writeTestFile(t, dir, "config.json", `{
+		"$include": "common/defaults.toml",
+		"debug": true,
+		"server": {
+			"$include": ["common/tls.json"],
+			"key": "key.pem"
+		},
+		"backends": [
+			{ "$include": "common/tls.json" }
+		]
+	}`)
+
+	c, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := Config{
+		"debug": true,
+		"log": map[string]interface{}{
+			"level": "info",
+		},
+		"server": map[string]interface{}{
+			"port": int64(80),
+			"cert": "cert.pem",
+			"key":  "key.pem",
+		},
+		"backends": []interface{}{
+			map[string]interface{}{
+				"cert": "cert.pem",
+				"key":  "default.pem",
+			},
+		},
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Fatalf("unexpected configuration: %#v", c)
+	}
+}
+
+func TestResolveIncludesNonStringKeys(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "tls.json", `{ "cert": "cert.pem", "key": "default.pem" }`)
+
+	// maps as produced by YAML libraries
+	c := Config{
+		"server": map[interface{}]interface{}{
+			"$include": "tls.json",
+			"key":      "key.pem",
+			"ports":    []interface{}{map[interface{}]interface{}{"$include": "tls.json"}},
+		},
+	}
+	if err := resolveIncludes(fileSystem{}, c, filepath.Join(dir, "config.yaml"), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := Config{
+		"server": map[string]interface{}{
+			"cert": "cert.pem",
+			"key":  "key.pem",
+			"ports": []interface{}{
+				map[string]interface{}{"cert": "cert.pem", "key": "default.pem"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Fatalf("unexpected configuration: %#v", c)
+	}
+}
+
+func TestLoadFileIncludeErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	// cycle
+	writeTestFile(t, dir, "a.json", `{ "$include": "b.json" }`)
+	writeTestFile(t, dir, "b.json", `{ "$include": "sub/../a.json" }`)
+	_, err := LoadFile(filepath.Join(dir, "a.json"))
+	if err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// depth
+	writeTestFile(t, dir, "deep.json", `{ "$include": "deep1.json" }`)
+	for i := 1; i <= MaxIncludeDepth; i++ {
+		writeTestFile(t, dir, "deep"+strconv.Itoa(i)+".json", `{ "$include": "deep`+strconv.Itoa(i+1)+`.json" }`)
+	}
+	writeTestFile(t, dir, "deep"+strconv.Itoa(MaxIncludeDepth+1)+".json", `{}`)
+	_, err = LoadFile(filepath.Join(dir, "deep.json"))
+	if err == nil || !strings.Contains(err.Error(), "maximum include depth") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err = LoadFile(filepath.Join(dir, "deep2.json")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// missing file
+	writeTestFile(t, dir, "missing.json", `{ "$include": "nonexistent.json" }`)
+	if _, err = LoadFile(filepath.Join(dir, "missing.json")); err == nil {
+		t.Fatalf("expected error, got none")
+	}
+
+	// invalid directive
+	writeTestFile(t, dir, "invalid.json", `{ "$include": [7] }`)
+	if _, err = LoadFile(filepath.Join(dir, "invalid.json")); err == nil {
+		t.Fatalf("expected error, got none")
+	}
+}
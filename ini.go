@@ -0,0 +1,226 @@
+package conf
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// INI parses the INI encoded data and stores the result in value. It can be
+// passed to Load or registered with RegisterFormat.
+//
+// Keys defined before the first section header are stored at the top level.
+// A section header "[section.sub]" is split at its dots and stored as nested
+// maps, so the keys of this section are accessible via "section.sub.key".
+// Lines starting with ';' or '#' are comments, as well as everything following
+// a ';' or '#' which is preceded by whitespace in unquoted values. Values may
+// be enclosed in double quotes (supporting the escape sequences \", \\, \n
+// and \t) or single quotes (no escapes). A backslash at the end of a line
+// continues the value on the next line. If a key is defined multiple times
+// within a section, its values are collected in a []interface{}. All values
+// are stored as strings. Syntax errors are reported as *SyntaxError.
+func INI(data []byte, value interface{}) error {
+	m, err := parseINI(string(data), nil)
+	if err != nil {
+		return err
+	}
+	return storeMap(m, value)
+}
+
+type iniLine struct {
+	text   string
+	number int
+	col    int // column of the first character of text
+}
+
+// parseINI parses the INI encoded data. If lines is not nil, the line of
+// each section and key is stored in it.
+func parseINI(data string, lines map[string]int) (map[string]interface{}, error) {
+	root := make(map[string]interface{})
+	section := root
+	sectionName := ""
+
+	for _, line := range iniLines(data) {
+		text := line.text
+		switch {
+		case text == "" || text[0] == ';' || text[0] == '#':
+			continue
+
+		case text[0] == '[':
+			end := strings.IndexByte(text, ']')
+			if end < 0 {
+				return nil, iniError(line, len(text), "expected ']'")
+			}
+			if rest := strings.TrimSpace(text[end+1:]); rest != "" && rest[0] != ';' && rest[0] != '#' {
+				return nil, iniError(line, end+1, "unexpected characters after section header")
+			}
+
+			sectionName = strings.TrimSpace(text[1:end])
+			if sectionName == "" {
+				return nil, iniError(line, 1, "empty section name")
+			}
+
+			section = root
+			keys := strings.Split(sectionName, ".")
+			for i, key := range keys {
+				key = strings.TrimSpace(key)
+				if key == "" {
+					return nil, iniError(line, 1, "invalid section name '%s'", sectionName)
+				}
+				keys[i] = key
+
+				switch v := section[key].(type) {
+				case nil:
+					next := make(map[string]interface{})
+					section[key] = next
+					section = next
+				case map[string]interface{}:
+					section = v
+				default:
+					return nil, iniError(line, 1, "section '%s' conflicts with key '%s'", sectionName, strings.Join(keys[:i+1], "."))
+				}
+			}
+			sectionName = strings.Join(keys, ".")
+			recordLine(lines, sectionName, line.number)
+
+		default:
+			sep := strings.IndexAny(text, "=:")
+			if sep < 0 {
+				return nil, iniError(line, 0, "expected '=' or ':' after key")
+			}
+			key := strings.TrimSpace(text[:sep])
+			if key == "" {
+				return nil, iniError(line, 0, "empty key")
+			}
+
+			valCol := sep + 1
+			for valCol < len(text) && (text[valCol] == ' ' || text[valCol] == '\t') {
+				valCol++
+			}
+			val, err := parseINIValue(text[valCol:])
+			if err != nil {
+				return nil, iniError(line, valCol+err.col, "%s", err.msg)
+			}
+
+			fullKey := key
+			if sectionName != "" {
+				fullKey = sectionName + "." + key
+			}
+			switch existing := section[key].(type) {
+			case nil:
+				section[key] = val
+				recordLine(lines, fullKey, line.number)
+			case map[string]interface{}:
+				return nil, iniError(line, 0, "key '%s' conflicts with a section", fullKey)
+			case []interface{}:
+				section[key] = append(existing, val)
+			default:
+				section[key] = []interface{}{existing, val}
+			}
+		}
+	}
+	return root, nil
+}
+
+// iniLines splits data into logical lines, joining lines ending with a
+// backslash with their successors. Leading and trailing whitespace of each
+// line is removed.
+func iniLines(data string) []iniLine {
+	var (
+		lines   []iniLine
+		pending *iniLine
+	)
+	for i, raw := range strings.Split(data, "\n") {
+		raw = strings.TrimRight(raw, " \t\r")
+		trimmed := strings.TrimLeft(raw, " \t")
+		col := len(raw) - len(trimmed)
+
+		if pending == nil {
+			pending = &iniLine{number: i + 1, col: col}
+		}
+		if strings.HasSuffix(trimmed, `\`) && !isINIComment(trimmed) {
+			pending.text += trimmed[:len(trimmed)-1]
+			continue
+		}
+		pending.text += trimmed
+		lines = append(lines, *pending)
+		pending = nil
+	}
+	if pending != nil {
+		lines = append(lines, *pending)
+	}
+	return lines
+}
+
+func isINIComment(line string) bool {
+	return line != "" && (line[0] == ';' || line[0] == '#')
+}
+
+type iniValueError struct {
+	col int
+	msg string
+}
+
+func parseINIValue(s string) (string, *iniValueError) {
+	if s == "" {
+		return "", nil
+	}
+
+	switch s[0] {
+	case '"', '\'':
+		quote := s[0]
+		var sb strings.Builder
+		i := 1
+		for ; i < len(s) && s[i] != quote; i++ {
+			c := s[i]
+			if c != '\\' || quote == '\'' {
+				sb.WriteByte(c)
+				continue
+			}
+
+			i++
+			if i == len(s) {
+				break
+			}
+			switch s[i] {
+			case '"', '\\':
+				sb.WriteByte(s[i])
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			default:
+				return "", &iniValueError{col: i - 1, msg: fmt.Sprintf("invalid escape sequence '\\%c'", s[i])}
+			}
+		}
+		if i >= len(s) {
+			return "", &iniValueError{col: len(s), msg: "unterminated quoted value"}
+		}
+
+		rest := strings.TrimLeft(s[i+1:], " \t")
+		if rest != "" && !isINIComment(rest) {
+			return "", &iniValueError{col: len(s) - len(rest), msg: "unexpected characters after quoted value"}
+		}
+		return sb.String(), nil
+	}
+
+	// strip inline comments
+	for i := 1; i < len(s); i++ {
+		if (s[i] == ';' || s[i] == '#') && (s[i-1] == ' ' || s[i-1] == '\t') {
+			return strings.TrimRight(s[:i], " \t"), nil
+		}
+	}
+	return s, nil
+}
+
+func iniError(line iniLine, col int, msg string, args ...interface{}) *SyntaxError {
+	if col > len(line.text) {
+		col = len(line.text)
+	}
+	return &SyntaxError{
+		Format: "ini",
+		Line:   line.number,
+		Column: line.col + utf8.RuneCountInString(line.text[:col]) + 1,
+		Msg:    fmt.Sprintf(msg, args...),
+	}
+}
@@ -0,0 +1,105 @@
+package conf
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestINI(t *testing.T) {
+	c, err := Load(bytes.NewBufferString(`
+; global settings
+name = myapp
+debug: true
+
+[server]
+address = 192.168.1.7 ; inline comment
+port = 8080
+motd = "Hello; \"World\"\n"
+path = 'C:\temp'
+description = a long \
+    description
+empty =
+
+[server.tls]
+cert = cert.pem
+# repeated keys
+ca = ca1.pem
+ca = ca2.pem
+ca = ca3.pem
+
+[ server ]
+hash = a#b
+`), INI)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := Config{
+		"name":  "myapp",
+		"debug": "true",
+		"server": map[string]interface{}{
+			"address":     "192.168.1.7",
+			"port":        "8080",
+			"motd":        "Hello; \"World\"\n",
+			"path":        `C:\temp`,
+			"description": "a long description",
+			"empty":       "",
+			"hash":        "a#b",
+			"tls": map[string]interface{}{
+				"cert": "cert.pem",
+				"ca":   []interface{}{"ca1.pem", "ca2.pem", "ca3.pem"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Fatalf("unexpected configuration: %#v", c)
+	}
+
+	var tls struct {
+		Cert string
+		CA   []string
+	}
+	if err := c.Decode("server.tls", &tls); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tls.Cert != "cert.pem" || len(tls.CA) != 3 || tls.CA[2] != "ca3.pem" {
+		t.Fatalf("unexpected tls configuration: %+v", tls)
+	}
+
+	var port int
+	if err := c.Decode("server.port", &port); err != nil || port != 8080 {
+		t.Fatalf("unexpected port: %v (%v)", port, err)
+	}
+}
+
+func TestINIErrors(t *testing.T) {
+	invalid := []struct {
+		input  string
+		line   int
+		column int
+	}{
+		{"[section", 1, 9},
+		{"[]", 1, 2},
+		{"[a..b]", 1, 2},
+		{"[a] foo", 1, 4},
+		{"foo", 1, 1},
+		{"  = bar", 1, 3},
+		{"a = \"bar", 1, 9},
+		{"a = \"b\\qr\"", 1, 7},
+		{"a = \"bar\" baz", 1, 11},
+		{"a = 1\n[a]", 2, 2},
+		{"[a.b]\n[a]\nb = 1", 3, 1},
+	}
+
+	for _, test := range invalid {
+		_, err := Load(bytes.NewBufferString(test.input), INI)
+		serr, ok := err.(*SyntaxError)
+		if !ok {
+			t.Fatalf("expected syntax error for %q, got %v", test.input, err)
+		}
+		if serr.Format != "ini" || serr.Line != test.line || serr.Column != test.column {
+			t.Fatalf("unexpected error for %q: %v", test.input, err)
+		}
+	}
+}
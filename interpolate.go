@@ -0,0 +1,228 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Interpolate returns a copy of the configuration in which all references
+// within string values are resolved. The following references are
+// supported:
+//
+//	${other.key}             the value of another key (see Config.Value)
+//	${env:VAR}               the value of the environment variable VAR
+//	${env:VAR:-default}      like ${env:VAR}, but default is used if the
+//	                         variable is not set or empty
+//
+// A string consisting of a single reference to another key takes over the
+// type of the referenced value (e.g. a number or a map). Otherwise the
+// referenced values are converted to strings. Referenced values may contain
+// references themselves. The sequence "$${" is replaced by a literal "${".
+// If a Secret (see Config.ResolveSecrets) is interpolated into a string,
+// the resulting value is a Secret as well.
+//
+// An error is returned if a reference cannot be resolved, if the references
+// are cyclic or if an environment variable without default is not set. The
+// error names the key which contains the broken reference. The configuration
+// c is not modified.
+func (c Config) Interpolate() (Config, error) {
+	ip := &interpolator{
+		root:  Merge(c), // deep copy
+		state: make(map[string]resolveState),
+	}
+	if _, err := ip.resolve(nil, map[string]interface{}(ip.root)); err != nil {
+		return nil, err
+	}
+	return ip.root, nil
+}
+
+type resolveState int
+
+const (
+	unresolved resolveState = iota
+	resolving
+	resolved
+)
+
+type interpolator struct {
+	root  Config
+	state map[string]resolveState // state of the string values
+}
+
+// resolve resolves all references in v, which is located at the given
+// path, and returns the result. Maps and slices are modified in place.
+func (ip *interpolator) resolve(path []string, v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		return ip.resolveString(path, v)
+
+	case map[string]interface{}:
+		for k, elem := range v {
+			r, err := ip.resolve(appendKeys(path, k), elem)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = r
+		}
+
+	case []interface{}:
+		for i, elem := range v {
+			r, err := ip.resolve(appendKeys(path, strconv.Itoa(i)), elem)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = r
+		}
+	}
+	return v, nil
+}
+
+func (ip *interpolator) resolveString(path []string, s string) (interface{}, error) {
+	key := Path(path).String()
+	switch ip.state[key] {
+	case resolved:
+		return s, nil
+	case resolving:
+		return nil, &interpolationError{key: key, err: errors.New("cyclic reference")}
+	}
+	ip.state[key] = resolving
+
+	var (
+		sb     strings.Builder
+		rest   = s
+		secret bool // a secret was interpolated
+	)
+	for {
+		i := strings.Index(rest, "${")
+		if i < 0 {
+			sb.WriteString(rest)
+			break
+		}
+		if i > 0 && rest[i-1] == '$' {
+			// escaped reference
+			sb.WriteString(rest[:i-1])
+			sb.WriteString("${")
+			rest = rest[i+2:]
+			continue
+		}
+
+		end := strings.IndexByte(rest[i:], '}')
+		if end < 0 {
+			return nil, &interpolationError{key: key, err: errors.New("unterminated reference")}
+		}
+		ref := rest[i+2 : i+end]
+
+		val, err := ip.reference(ref)
+		if err != nil {
+			if _, nested := err.(*interpolationError); nested {
+				return nil, err
+			}
+			return nil, &interpolationError{key: key, err: err}
+		}
+		if i == 0 && end == len(rest)-1 && sb.Len() == 0 && len(rest) == len(s) {
+			// the whole string is a single reference, maps and slices are
+			// copied, so they are not shared with the referenced key
+			ip.state[key] = resolved
+			return Merger{}.merge(nil, val), nil
+		}
+
+		if _, ok := val.(Secret); ok {
+			secret = true
+		}
+		var str string
+		if err := decode(reflect.ValueOf(&str), reflect.ValueOf(val)); err != nil {
+			return nil, &interpolationError{key: key, err: err}
+		}
+		sb.WriteString(rest[:i])
+		sb.WriteString(str)
+		rest = rest[i+end+1:]
+	}
+
+	ip.state[key] = resolved
+	if secret {
+		// the result must not reveal the secret in dumps
+		return Secret(sb.String()), nil
+	}
+	return sb.String(), nil
+}
+
+// reference returns the resolved value of the given reference.
+func (ip *interpolator) reference(ref string) (interface{}, error) {
+	if name := strings.TrimPrefix(ref, "env:"); name != ref {
+		def, hasDefault := "", false
+		if i := strings.Index(name, ":-"); i >= 0 {
+			name, def, hasDefault = name[:i], name[i+2:], true
+		}
+
+		val, has := os.LookupEnv(name)
+		switch {
+		case hasDefault && val == "":
+			return def, nil
+		case !has:
+			return nil, fmt.Errorf("environment variable '%s' is not set", name)
+		}
+		return val, nil
+	}
+
+	segs, err := parseKey(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	// Walk the copy of the configuration, so the resolved value can be
+	// stored in its container.
+	var (
+		v    interface{} = map[string]interface{}(ip.root)
+		keys []string
+		set  func(interface{})
+	)
+	for _, seg := range segs {
+		switch parent := v.(type) {
+		case map[string]interface{}:
+			name := seg.name
+			elem, has := parent[name]
+			if seg.index || !has {
+				return nil, fmt.Errorf("key not found: %s", ref)
+			}
+			v, set = elem, func(r interface{}) { parent[name] = r }
+			keys = append(keys, name)
+
+		case []interface{}:
+			idx, err := strconv.Atoi(seg.name)
+			if err != nil {
+				return nil, fmt.Errorf("key not found: %s", ref)
+			}
+			if idx < 0 {
+				idx += len(parent)
+			}
+			if idx < 0 || idx >= len(parent) {
+				return nil, fmt.Errorf("index out of range: %s (length %d)", ref[:seg.end], len(parent))
+			}
+			v, set = parent[idx], func(r interface{}) { parent[idx] = r }
+			keys = append(keys, strconv.Itoa(idx))
+
+		default:
+			return nil, fmt.Errorf("key not found: %s", ref)
+		}
+	}
+
+	r, err := ip.resolve(keys, v)
+	if err != nil {
+		return nil, err
+	}
+	set(r)
+	return r, nil
+}
+
+type interpolationError struct {
+	key string
+	err error
+}
+
+func (e *interpolationError) Error() string {
+	return fmt.Sprintf("cannot interpolate key '%s': %v", e.key, e.err)
+}
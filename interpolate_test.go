@@ -0,0 +1,123 @@
+package conf
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfigInterpolate(t *testing.T) {
+	t.Setenv("CONF_TEST_HOST", "example.com")
+	unsetEnv(t, "CONF_TEST_UNSET")
+
+	c := Config{
+		"server": map[string]interface{}{
+			"host": "${env:CONF_TEST_HOST}",
+			"port": 8080,
+			"addr": "${server.host}:${server.port}",
+		},
+		"url":     "http://${server.addr}/",
+		"port":    "${server.port}",
+		"server2": "${server}",
+		"user":    "${env:CONF_TEST_UNSET:-nobody}",
+		"escaped": "$${server.host} costs $5",
+		"list":    []interface{}{"${user}", 1},
+		"first":   "${list[0]}/${list.-1}",
+	}
+
+	res, err := c.Interpolate()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"server.addr":  "example.com:8080",
+		"url":          "http://example.com:8080/",
+		"port":         8080,
+		"server2.host": "example.com",
+		"user":         "nobody",
+		"escaped":      "${server.host} costs $5",
+		"first":        "nobody/1",
+	}
+	for key, exp := range expected {
+		v, err := res.Value(key)
+		if err != nil {
+			t.Fatalf("unexpected error for key %s: %v", key, err)
+		}
+		if (*reflect.Value)(v).Interface() != exp {
+			t.Fatalf("unexpected value for key %s: %v", key, (*reflect.Value)(v).Interface())
+		}
+	}
+	if list := res["list"].([]interface{}); list[0] != "nobody" {
+		t.Fatalf("unexpected list element: %v", list[0])
+	}
+
+	// referenced maps are copied
+	if err := res.Set("server2.host", "other.com"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if host := res["server"].(map[string]interface{})["host"]; host != "example.com" {
+		t.Fatalf("referenced map modified: %v", host)
+	}
+
+	// the original configuration is not modified
+	if c["url"] != "http://${server.addr}/" || c["server"].(map[string]interface{})["addr"] != "${server.host}:${server.port}" {
+		t.Fatalf("original configuration modified")
+	}
+}
+
+func TestConfigInterpolateErrors(t *testing.T) {
+	unsetEnv(t, "CONF_TEST_UNSET")
+
+	tests := []struct {
+		config Config
+		errMsg string
+	}{
+		{
+			config: Config{"a": map[string]interface{}{"b": "${missing}"}},
+			errMsg: "cannot interpolate key 'a.b': key not found: missing",
+		},
+		{
+			config: Config{"a": "x${env:CONF_TEST_UNSET}"},
+			errMsg: "cannot interpolate key 'a': environment variable 'CONF_TEST_UNSET' is not set",
+		},
+		{
+			config: Config{"a": "${b"},
+			errMsg: "cannot interpolate key 'a': unterminated reference",
+		},
+		{
+			config: Config{"a": "${b}", "b": "${c}", "c": "${a}"},
+			errMsg: "cyclic reference",
+		},
+		{
+			config: Config{"a": map[string]interface{}{"b": "${a}"}},
+			errMsg: "cannot interpolate key 'a.b': cyclic reference",
+		},
+		{
+			config: Config{"a": "${b[1]}", "b": []interface{}{1}},
+			errMsg: "cannot interpolate key 'a': index out of range: b[1] (length 1)",
+		},
+		{
+			config: Config{"a": "x", "b": "${c}", "c": "${missing}"},
+			errMsg: "cannot interpolate key 'c': key not found: missing",
+		},
+	}
+
+	for _, test := range tests {
+		_, err := test.config.Interpolate()
+		if err == nil {
+			t.Fatalf("error expected for %v", test.config)
+		}
+		if !strings.HasSuffix(err.Error(), test.errMsg) {
+			t.Fatalf("unexpected error for %v: %v", test.config, err)
+		}
+	}
+}
+
+// unsetEnv unsets the environment variable with the given name for the
+// duration of the test. The previous value is restored afterwards.
+func unsetEnv(t *testing.T, name string) {
+	t.Setenv(name, "") // restores the variable on cleanup
+	os.Unsetenv(name)
+}
@@ -0,0 +1,180 @@
+package conf
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// KVStore represents a hierarchical key-value store like etcd or Consul.
+// The keys form a hierarchy with slashes as separators, e.g.
+// "app/server/port".
+type KVStore interface {
+	// List returns all key-value pairs whose keys start with prefix.
+	List(ctx context.Context, prefix string) (map[string]string, error)
+
+	// Watch returns a channel which receives a value whenever a key which
+	// starts with prefix is changed or deleted. Several changes may be
+	// combined into a single notification. The channel is closed when ctx
+	// is done.
+	Watch(ctx context.Context, prefix string) <-chan struct{}
+}
+
+// KVSource returns a source which loads the configuration from all keys in
+// store which start with prefix. The prefix is removed from the keys, and
+// the remainders are split at their slashes to build the configuration
+// hierarchy, e.g. with the prefix "app/" the key "app/server/port" maps to
+// the configuration key "server.port". The prefix always ends at a slash,
+// i.e. the prefix "app" is treated like "app/" and does not match the key
+// "application/name". Keys ending with a slash, which represent folders in
+// stores like Consul, are ignored. All values are stored as strings. It is
+// an error if a key has a value as well as sub-keys.
+func KVSource(store KVStore, prefix string) WatchableSource {
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return &kvSource{
+		store:  store,
+		prefix: prefix,
+	}
+}
+
+type kvSource struct {
+	store  KVStore
+	prefix string
+}
+
+func (s *kvSource) Load(ctx context.Context) (Config, error) {
+	pairs, err := s.store.List(ctx, s.prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	c := Config{}
+	for key, val := range pairs {
+		if key == s.prefix || strings.HasSuffix(key, "/") {
+			continue // folder key (e.g. Consul)
+		}
+		keys := strings.Split(strings.Trim(strings.TrimPrefix(key, s.prefix), "/"), "/")
+		for _, k := range keys {
+			if k == "" {
+				return nil, fmt.Errorf("invalid key '%s'", key)
+			}
+		}
+		if err := insert(c, keys, val); err != nil {
+			return nil, fmt.Errorf("invalid key '%s': %v", key, err)
+		}
+	}
+	return c, nil
+}
+
+func (s *kvSource) Watch(ctx context.Context) <-chan Event {
+	changes := s.store.Watch(ctx, s.prefix)
+	ch := make(chan Event)
+	go func() {
+		defer close(ch)
+		for range changes {
+			c, err := s.Load(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			sendEvent(ctx, ch, Event{Config: c, Err: err})
+		}
+	}()
+	return ch
+}
+
+// MemoryKV is an in-memory KVStore. It is safe for concurrent use. The
+// zero value is an empty store.
+type MemoryKV struct {
+	mu       sync.Mutex
+	pairs    map[string]string
+	watchers map[*kvWatcher]struct{}
+}
+
+type kvWatcher struct {
+	prefix string
+	ch     chan struct{}
+}
+
+// Set sets the value of key and notifies the watchers of the key.
+func (kv *MemoryKV) Set(key, value string) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	if kv.pairs == nil {
+		kv.pairs = make(map[string]string)
+	}
+	kv.pairs[key] = value
+	kv.notify(key)
+}
+
+// Delete deletes key and notifies the watchers of the key. Deleting a key
+// which does not exist is a no-op.
+func (kv *MemoryKV) Delete(key string) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	if _, has := kv.pairs[key]; has {
+		delete(kv.pairs, key)
+		kv.notify(key)
+	}
+}
+
+// List returns all key-value pairs whose keys start with prefix.
+func (kv *MemoryKV) List(ctx context.Context, prefix string) (map[string]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	pairs := make(map[string]string)
+	for k, v := range kv.pairs {
+		if strings.HasPrefix(k, prefix) {
+			pairs[k] = v
+		}
+	}
+	return pairs, nil
+}
+
+// Watch returns a channel which receives a value whenever a key which
+// starts with prefix is changed or deleted. The channel is closed when ctx
+// is done.
+func (kv *MemoryKV) Watch(ctx context.Context, prefix string) <-chan struct{} {
+	w := &kvWatcher{
+		prefix: prefix,
+		ch:     make(chan struct{}, 1),
+	}
+
+	kv.mu.Lock()
+	if kv.watchers == nil {
+		kv.watchers = make(map[*kvWatcher]struct{})
+	}
+	kv.watchers[w] = struct{}{}
+	kv.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		kv.mu.Lock()
+		delete(kv.watchers, w)
+		close(w.ch)
+		kv.mu.Unlock()
+	}()
+	return w.ch
+}
+
+// notify notifies the watchers of key. It must be called with kv.mu held.
+func (kv *MemoryKV) notify(key string) {
+	for w := range kv.watchers {
+		if strings.HasPrefix(key, w.prefix) {
+			select {
+			case w.ch <- struct{}{}:
+			default:
+				// a notification is pending already
+			}
+		}
+	}
+}
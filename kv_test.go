@@ -0,0 +1,97 @@
+package conf
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestKVSource(t *testing.T) {
+	kv := &MemoryKV{}
+	kv.Set("app/server/host", "localhost")
+	kv.Set("app/server/port", "8080")
+	kv.Set("app/name", "test")
+	kv.Set("other/name", "other")
+	kv.Set("application/name", "other")
+	kv.Set("app/", "")
+	kv.Set("app/server/", "")
+
+	src := KVSource(kv, "app")
+	c, err := src.Load(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"server.host": "localhost",
+		"server.port": "8080",
+		"name":        "test",
+	}
+	for key, exp := range expected {
+		v, err := c.Value(key)
+		if err != nil {
+			t.Fatalf("unexpected error for key %s: %v", key, err)
+		}
+		if s, _ := v.String(); s != exp {
+			t.Fatalf("unexpected value for key %s: %s", key, s)
+		}
+	}
+	if len(c) != 2 {
+		t.Fatalf("unexpected configuration: %v", c)
+	}
+
+	kv.Set("app/name/first", "conflict")
+	if _, err := src.Load(context.Background()); err == nil {
+		t.Fatalf("expected error, got none")
+	}
+}
+
+func TestKVSourceWatch(t *testing.T) {
+	kv := &MemoryKV{}
+	kv.Set("app/port", "8080")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := KVSource(kv, "app/").Watch(ctx)
+
+	kv.Set("other/port", "1") // ignored
+	kv.Set("app/port", "9090")
+	ev := nextEvent(t, events)
+	if ev.Err != nil {
+		t.Fatalf("unexpected error: %v", ev.Err)
+	}
+	if ev.Config["port"] != "9090" {
+		t.Fatalf("unexpected configuration: %v", ev.Config)
+	}
+
+	kv.Delete("app/port")
+	ev = nextEvent(t, events)
+	if ev.Err != nil {
+		t.Fatalf("unexpected error: %v", ev.Err)
+	}
+	if len(ev.Config) != 0 {
+		t.Fatalf("unexpected configuration: %v", ev.Config)
+	}
+
+	cancel()
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Fatalf("unexpected event")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for close")
+	}
+}
+
+func nextEvent(t *testing.T, events <-chan Event) Event {
+	select {
+	case ev, ok := <-events:
+		if !ok {
+			t.Fatalf("event channel closed")
+		}
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for event")
+	}
+	return Event{}
+}
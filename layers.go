@@ -0,0 +1,231 @@
+package conf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Origin describes where a configuration value was defined.
+type Origin struct {
+	Source string // name of the layer which defined the value
+	File   string // file which defined the value (empty if unknown)
+	Line   int    // line within File (0 if unknown)
+}
+
+// String returns the origin in the form "file:line" if the location is
+// known, otherwise the source name is returned.
+func (o Origin) String() string {
+	switch {
+	case o.File != "" && o.Line > 0:
+		return o.File + ":" + strconv.Itoa(o.Line)
+	case o.File != "":
+		return o.File
+	}
+	return o.Source
+}
+
+// Layers represents a stack of configuration layers which keeps track of
+// the origin of each configuration value. The layers are merged in the
+// order they were added, so later layers take precedence over earlier ones.
+// The zero value is an empty stack which merges with the default options.
+type Layers struct {
+	// Merger defines how the layers are merged.
+	Merger Merger
+
+	layers []layer
+}
+
+type layer struct {
+	name   string
+	file   string
+	config Config
+	lines  map[string]int // line of each key (nil if unknown)
+}
+
+// Add adds the configuration c as a new layer with the given name (e.g.
+// "defaults", "env" or "flags").
+func (l *Layers) Add(name string, c Config) {
+	l.layers = append(l.layers, layer{
+		name:   name,
+		config: c,
+	})
+}
+
+// AddFile loads the configuration file with the given path (see LoadFile)
+// and adds it as a new layer. The layer is named after the path. For the
+// built-in formats, except JSON, the origin of each value includes its line
+// within the file. Included values are attributed to the including file.
+func (l *Layers) AddFile(path string) error {
+	return l.addFile(fileSystem{}, path)
+}
+
+// AddFileFS is like AddFile, but loads the file with the given name from
+// fsys (see LoadFS).
+func (l *Layers) AddFileFS(fsys fs.FS, name string) error {
+	return l.addFile(fileSystem{fsys}, name)
+}
+
+func (l *Layers) addFile(fsys fileSystem, path string) error {
+	ly := layer{
+		name: path,
+		file: path,
+	}
+	if parse := lookupParser(fsys.ext(path)); parse != nil {
+		data, err := fsys.readFile(path)
+		if err != nil {
+			return err
+		}
+		ly.lines = make(map[string]int)
+		m, err := parse(string(data), ly.lines)
+		if err != nil {
+			return err
+		}
+		ly.config = Config(m)
+	} else {
+		c, _, err := fsys.load(path)
+		if err != nil {
+			return err
+		}
+		ly.config = c
+	}
+	if err := resolveIncludes(fsys, ly.config, path, nil); err != nil {
+		return err
+	}
+
+	l.layers = append(l.layers, ly)
+	return nil
+}
+
+// Config returns the merged configuration of all layers.
+func (l *Layers) Config() Config {
+	configs := make([]Config, len(l.layers))
+	for i, ly := range l.layers {
+		configs[i] = ly.config
+	}
+	return l.Merger.Merge(configs...)
+}
+
+// Origin returns the origin of the effective value with the given key,
+// i.e. the topmost layer which defines the key. If the merged configuration
+// does not contain the key an error is returned.
+func (l *Layers) Origin(key string) (Origin, error) {
+	if _, err := l.Config().value(key); err != nil {
+		return Origin{}, err
+	}
+
+	keys, err := ParsePath(key)
+	if err != nil {
+		return Origin{}, err
+	}
+	for i := len(l.layers) - 1; i >= 0; i-- {
+		if _, lkeys, err := l.layers[i].value(keys); err == nil {
+			return l.layers[i].origin(lkeys), nil
+		}
+	}
+	return Origin{}, fmt.Errorf("key not found: %s", key)
+}
+
+// Explain writes a description of all configuration values to w. For each
+// key the effective value and its origin is written, followed by the values
+// of the lower layers which are shadowed by the effective value. The keys
+// are sorted alphabetically.
+//
+// Example output:
+//   server.port = 9090 (env)
+//     shadowed: 8080 (config.toml:3)
+//     shadowed: 80 (defaults)
+func (l *Layers) Explain(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	walkLeaves(l.Config(), nil, func(keys []string, v interface{}) {
+		key := Path(keys).String()
+
+		shadowing := true
+		for i := len(l.layers) - 1; i >= 0; i-- {
+			ly := l.layers[i]
+			val, lkeys, err := ly.value(keys)
+			if err != nil {
+				continue
+			}
+
+			if shadowing {
+				fmt.Fprintf(bw, "%s = %v (%s)\n", key, v, ly.origin(lkeys))
+				shadowing = false
+			} else {
+				fmt.Fprintf(bw, "  shadowed: %v (%s)\n", val.Interface(), ly.origin(lkeys))
+			}
+		}
+	})
+	return bw.Flush()
+}
+
+// value returns the value of the key with the given segments within the
+// layer and the segments as spelled by the layer. Map keys which are not
+// defined by the layer are matched case-insensitively, as done by Merge.
+func (ly layer) value(keys []string) (reflect.Value, []string, error) {
+	lkeys := make([]string, len(keys))
+	m := map[string]interface{}(ly.config)
+	for i, k := range keys {
+		if _, has := m[k]; !has && m != nil {
+			match := ""
+			for name := range m {
+				if strings.EqualFold(name, k) {
+					if match != "" {
+						match = "" // ambiguous
+						break
+					}
+					match = name
+				}
+			}
+			if match != "" {
+				k = match
+			}
+		}
+		lkeys[i] = k
+		m, _ = asMap(m[k])
+	}
+
+	val, err := ly.config.value(Path(lkeys).String())
+	return val, lkeys, err
+}
+
+// origin returns the origin of the key with the given segments within the
+// layer. If the line of the key is unknown, the line of its nearest parent
+// is used.
+func (ly layer) origin(keys []string) Origin {
+	o := Origin{
+		Source: ly.name,
+		File:   ly.file,
+	}
+	for n := len(keys); ly.lines != nil && n > 0; n-- {
+		if line, has := ly.lines[strings.Join(keys[:n], ".")]; has {
+			o.Line = line
+			break
+		}
+	}
+	return o
+}
+
+// walkLeaves calls fn for each value in m which is not a map. The values
+// are visited in the alphabetical order of their keys.
+func walkLeaves(m map[string]interface{}, keys []string, fn func(keys []string, v interface{})) {
+	names := make([]string, 0, len(m))
+	for k := range m {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		k := append(keys[:len(keys):len(keys)], name)
+		if sub, ok := asMap(m[name]); ok {
+			walkLeaves(sub, k, fn)
+		} else {
+			fn(k, m[name])
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package conf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLayers(t *testing.T) {
+	dir := t.TempDir()
+	tomlPath := writeTestFile(t, dir, "config.toml", `
+[server]
+address = "192.168.1.7"
+port = 8080
+tls = { cert = "cert.pem" }
+`)
+	jsonPath := writeTestFile(t, dir, "local.json", `{ "server": { "address": "127.0.0.1" } }`)
+
+	var l Layers
+	l.Add("defaults", Config{
+		"server": map[string]interface{}{
+			"port":    80,
+			"timeout": "5s",
+		},
+	})
+	if err := l.AddFile(tomlPath); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := l.AddFile(jsonPath); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	l.Add("env", Config{
+		"server": map[string]interface{}{
+			"port": "9090",
+		},
+	})
+
+	var port int
+	if err := l.Config().Decode("server.port", &port); err != nil || port != 9090 {
+		t.Fatalf("unexpected port: %v (%v)", port, err)
+	}
+
+	origins := map[string]Origin{
+		"server.port":     {Source: "env"},
+		"server.timeout":  {Source: "defaults"},
+		"server.address":  {Source: jsonPath, File: jsonPath},
+		"server.tls.cert": {Source: tomlPath, File: tomlPath, Line: 5},
+		"server.tls":      {Source: tomlPath, File: tomlPath, Line: 5},
+	}
+	for key, expected := range origins {
+		o, err := l.Origin(key)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if o != expected {
+			t.Fatalf("unexpected origin of '%s': %+v", key, o)
+		}
+	}
+
+	if _, err := l.Origin("server.missing"); err == nil {
+		t.Fatalf("expected error, got none")
+	}
+
+	var buf bytes.Buffer
+	if err := l.Explain(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "server.address = 127.0.0.1 (" + jsonPath + ")\n" +
+		"  shadowed: 192.168.1.7 (" + tomlPath + ":3)\n" +
+		"server.port = 9090 (env)\n" +
+		"  shadowed: 8080 (" + tomlPath + ":4)\n" +
+		"  shadowed: 80 (defaults)\n" +
+		"server.timeout = 5s (defaults)\n" +
+		"server.tls.cert = cert.pem (" + tomlPath + ":5)\n"
+	if buf.String() != expected {
+		t.Fatalf("unexpected explanation:\n%s", buf.String())
+	}
+}
+
+func TestLayersFoldedKeys(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTestFile(t, dir, "config.toml", "[db]\nmaxConns = 10\n")
+
+	var l Layers
+	if err := l.AddFile(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	l.Add("env", Config{
+		"db": map[string]interface{}{
+			"maxconns": "99",
+		},
+	})
+
+	o, err := l.Origin("db.maxConns")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if o != (Origin{Source: "env"}) {
+		t.Fatalf("unexpected origin: %+v", o)
+	}
+
+	var buf bytes.Buffer
+	if err := l.Explain(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "db.maxConns = 99 (env)\n" +
+		"  shadowed: 10 (" + path + ":2)\n"
+	if buf.String() != expected {
+		t.Fatalf("unexpected explanation:\n%s", buf.String())
+	}
+}
+
+func TestLayersLines(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"config.ini":        "[server]\n\nport = 8080\n",
+		"config.env":        "A=1\n\nSERVER__PORT=8080\n",
+		"config.properties": "# comment\n\nserver.port = 8080\n",
+	}
+	for name, content := range files {
+		var l Layers
+		if err := l.AddFile(writeTestFile(t, dir, name, content)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		key := "server.port"
+		if name == "config.env" {
+			key = "SERVER__PORT"
+		}
+		o, err := l.Origin(key)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if o.Line != 3 {
+			t.Fatalf("unexpected origin for %s: %+v", name, o)
+		}
+	}
+
+	var l Layers
+	if err := l.AddFile(writeTestFile(t, dir, "invalid.toml", "foo")); err == nil {
+		t.Fatalf("expected error, got none")
+	}
+}
@@ -0,0 +1,199 @@
+package conf
+
+import (
+	"reflect"
+	"strings"
+)
+
+// SliceMode defines how Merger combines two slices stored under the
+// same key.
+type SliceMode int
+
+const (
+	// SliceReplace replaces the slice of a lower layer with the slice of
+	// the higher layer.
+	SliceReplace SliceMode = iota
+
+	// SliceAppend appends the elements of the slice of the higher layer to
+	// the slice of the lower layer.
+	SliceAppend
+
+	// SliceMerge merges the slices element-wise. Elements at the same index
+	// are merged like any other value, i.e. maps are merged recursively and
+	// all other values are replaced. If one of the slices is longer than
+	// the other, its remaining elements are kept.
+	SliceMerge
+)
+
+// Merger merges several configuration layers into a single configuration.
+// The zero value merges with the default options.
+type Merger struct {
+	// Slices defines how slices are merged. The default is SliceReplace.
+	Slices SliceMode
+}
+
+// Merge merges the given configuration layers with the default options.
+// See Merger.Merge for details.
+func Merge(layers ...Config) Config {
+	return Merger{}.Merge(layers...)
+}
+
+// Merge deep-merges the given configuration layers into a new configuration.
+// Later layers take precedence over earlier ones. Nested maps are merged
+// recursively and slices ([]interface{} and []string) are combined as
+// defined by m.Slices. All other values of a later layer (including nil
+// values and other slice types like []byte) replace the values of the
+// earlier layers.
+//
+// Keys are compared case-insensitively across layers: if a layer contains
+// a key which is not defined by the earlier layers, but differs from one of
+// their keys only in case (e.g. "maxconns" and "maxConns"), the value is
+// stored under the earlier key. This way environment variables and flags,
+// whose keys are lower-cased, override the keys of a configuration file as
+// seen by Config.Decode. Keys within the same layer are never folded.
+//
+// If the types of the values of two layers conflict, the value of the later
+// layer wins: a map replaces a scalar value or a slice and vice versa. The
+// given layers are never modified.
+func (m Merger) Merge(layers ...Config) Config {
+	res := Config{}
+	for _, layer := range layers {
+		m.mergeMaps(res, layer)
+	}
+	return res
+}
+
+// mergeMaps merges src into dst. Nested maps and slices of src are
+// copied, so subsequent merges into dst do not modify src.
+func (m Merger) mergeMaps(dst, src map[string]interface{}) {
+	folded := foldKeys(dst, src)
+	for k, v := range src {
+		if fk, has := folded[k]; has {
+			k = fk
+		}
+		dst[k] = m.merge(dst[k], v)
+	}
+}
+
+// foldKeys maps the keys of src which are not in dst to the keys of dst
+// which differ only in case. Keys with ambiguous matches and keys whose
+// match is also defined in src are not mapped.
+func foldKeys(dst, src map[string]interface{}) map[string]string {
+	var lower map[string]string // lower-cased key => key of dst ("" if ambiguous)
+	var folded map[string]string
+	for k := range src {
+		if _, has := dst[k]; has {
+			continue
+		}
+		if lower == nil {
+			lower = make(map[string]string, len(dst))
+			for dk := range dst {
+				lk := strings.ToLower(dk)
+				if _, dup := lower[lk]; dup {
+					dk = ""
+				}
+				lower[lk] = dk
+			}
+		}
+		dk := lower[strings.ToLower(k)]
+		if _, inSrc := src[dk]; dk == "" || inSrc {
+			continue
+		}
+		if folded == nil {
+			folded = make(map[string]string)
+		}
+		folded[k] = dk
+	}
+	return folded
+}
+
+// merge merges the value src into the value dst and returns the result.
+// The dst value must have been created by a previous merge, so it can be
+// modified in place.
+func (m Merger) merge(dst, src interface{}) interface{} {
+	if sm, ok := asMap(src); ok {
+		dm, ok := dst.(map[string]interface{})
+		if !ok {
+			dm = make(map[string]interface{}, len(sm))
+		}
+		m.mergeMaps(dm, sm)
+		return dm
+	}
+
+	ss, ok := asSlice(src)
+	if !ok {
+		return src
+	}
+
+	ds, _ := dst.([]interface{})
+	switch m.Slices {
+	case SliceAppend:
+		for _, v := range ss {
+			ds = append(ds, m.merge(nil, v))
+		}
+
+	case SliceMerge:
+		for i, v := range ss {
+			if i < len(ds) {
+				ds[i] = m.merge(ds[i], v)
+			} else {
+				ds = append(ds, m.merge(nil, v))
+			}
+		}
+
+	default:
+		ds = make([]interface{}, len(ss))
+		for i, v := range ss {
+			ds[i] = m.merge(nil, v)
+		}
+	}
+
+	if ds == nil {
+		ds = []interface{}{}
+	}
+	return ds
+}
+
+// asMap returns v as map with string keys. Maps with other key types whose
+// keys can be represented as strings (e.g. map[interface{}]interface{} as
+// produced by YAML libraries) are converted to a new map.
+func asMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case Config:
+		return m, true
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map || !isStringableKind(rv.Type().Key().Kind()) {
+		return nil, false
+	}
+	m := make(map[string]interface{}, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		k, ok := keyString(iter.Key())
+		if !ok {
+			return nil, false
+		}
+		m[k] = iter.Value().Interface()
+	}
+	return m, true
+}
+
+// asSlice returns v as slice of values. Only []interface{} and []string
+// are considered slices; all other slices (e.g. []byte) are treated like
+// scalar values, so their type is preserved.
+func asSlice(v interface{}) ([]interface{}, bool) {
+	switch s := v.(type) {
+	case []interface{}:
+		return s, true
+	case []string:
+		res := make([]interface{}, len(s))
+		for i, e := range s {
+			res[i] = e
+		}
+		return res, true
+	}
+	return nil, false
+}
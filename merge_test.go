@@ -0,0 +1,187 @@
+package conf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	defaults := Config{
+		"server": map[string]interface{}{
+			"address": "0.0.0.0",
+			"port":    80,
+			"tls":     false,
+		},
+		"tags":  []interface{}{"a"},
+		"debug": false,
+	}
+	base := Config{
+		"server": map[string]interface{}{
+			"port": 8080,
+			"tls": map[string]interface{}{
+				"cert": "cert.pem",
+			},
+		},
+		"tags": []string{"b", "c"},
+	}
+	overrides := Config{
+		"server": map[string]interface{}{
+			"tls": map[string]interface{}{
+				"key": "key.pem",
+			},
+		},
+		"debug": map[string]interface{}{
+			"level": "info",
+		},
+	}
+
+	c := Merge(defaults, base, overrides)
+	expected := Config{
+		"server": map[string]interface{}{
+			"address": "0.0.0.0",
+			"port":    8080,
+			"tls": map[string]interface{}{
+				"cert": "cert.pem",
+				"key":  "key.pem",
+			},
+		},
+		"tags": []interface{}{"b", "c"},
+		"debug": map[string]interface{}{
+			"level": "info",
+		},
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Fatalf("unexpected configuration: %#v", c)
+	}
+
+	// scalar replaces map
+	c = Merge(c, Config{"server": "none"})
+	if c["server"] != "none" {
+		t.Fatalf("unexpected configuration: %#v", c)
+	}
+
+	// layers must not be modified
+	if defaults["server"].(map[string]interface{})["tls"] != false ||
+		len(base["server"].(map[string]interface{})) != 2 ||
+		len(overrides["server"].(map[string]interface{})["tls"].(map[string]interface{})) != 1 {
+
+		t.Fatalf("layers were modified")
+	}
+}
+
+func TestMergeTypedSlices(t *testing.T) {
+	c := Config{
+		"data":  []byte("hi"),
+		"ports": []int{80, 443},
+	}.Overlay(Config{})
+
+	if data, ok := c["data"].([]byte); !ok || string(data) != "hi" {
+		t.Fatalf("unexpected data: %#v", c["data"])
+	}
+	if ports, ok := c["ports"].([]int); !ok || len(ports) != 2 {
+		t.Fatalf("unexpected ports: %#v", c["ports"])
+	}
+
+	var s string
+	if err := c.Decode("data", &s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s != "hi" {
+		t.Fatalf("unexpected string: %q", s)
+	}
+}
+
+func TestMergeFoldKeys(t *testing.T) {
+	c := Merge(
+		Config{"db": map[string]interface{}{"maxConns": 10, "Host": "a", "host": "b"}},
+		Config{"db": map[string]interface{}{"maxconns": 20, "HOST": "c", "user": "u", "User": "U"}},
+		Config{"DB": map[string]interface{}{"MAXCONNS": 30}},
+	)
+
+	expected := Config{
+		"db": map[string]interface{}{
+			"maxConns": 30,
+			"Host":     "a",
+			"host":     "b",
+			"HOST":     "c", // ambiguous
+			"user":     "u", // same layer
+			"User":     "U",
+		},
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Fatalf("unexpected configuration: %#v", c)
+	}
+}
+
+func TestMergeNonStringKeys(t *testing.T) {
+	// maps as produced by YAML libraries
+	base := Config{
+		"server": map[interface{}]interface{}{
+			"host": "localhost",
+			"port": 80,
+			"ids":  map[int]string{1: "a"},
+		},
+	}
+	c := base.Overlay(Config{"server": map[string]interface{}{"port": "8080"}})
+
+	expected := Config{
+		"server": map[string]interface{}{
+			"host": "localhost",
+			"port": "8080",
+			"ids":  map[string]interface{}{"1": "a"},
+		},
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Fatalf("unexpected configuration: %#v", c)
+	}
+}
+
+func TestMergerSlices(t *testing.T) {
+	a := Config{
+		"list": []interface{}{
+			map[string]interface{}{"name": "a", "port": 1},
+			map[string]interface{}{"name": "b", "port": 2},
+		},
+	}
+	b := Config{
+		"list": []interface{}{
+			map[string]interface{}{"port": 3},
+		},
+	}
+
+	c := Merger{Slices: SliceReplace}.Merge(a, b)
+	if !reflect.DeepEqual(c["list"], []interface{}{
+		map[string]interface{}{"port": 3},
+	}) {
+		t.Fatalf("unexpected slice: %#v", c["list"])
+	}
+
+	c = Merger{Slices: SliceAppend}.Merge(a, b)
+	if !reflect.DeepEqual(c["list"], []interface{}{
+		map[string]interface{}{"name": "a", "port": 1},
+		map[string]interface{}{"name": "b", "port": 2},
+		map[string]interface{}{"port": 3},
+	}) {
+		t.Fatalf("unexpected slice: %#v", c["list"])
+	}
+
+	c = Merger{Slices: SliceMerge}.Merge(a, b)
+	if !reflect.DeepEqual(c["list"], []interface{}{
+		map[string]interface{}{"name": "a", "port": 3},
+		map[string]interface{}{"name": "b", "port": 2},
+	}) {
+		t.Fatalf("unexpected slice: %#v", c["list"])
+	}
+
+	// appending must not modify the layers
+	Merger{Slices: SliceAppend}.Merge(a, b, b)
+	if len(a["list"].([]interface{})) != 2 || len(b["list"].([]interface{})) != 1 {
+		t.Fatalf("layers were modified")
+	}
+
+	// scalar replaces slice
+	c = Merger{Slices: SliceAppend}.Merge(a, Config{"list": 7})
+	if c["list"] != 7 {
+		t.Fatalf("unexpected value: %#v", c["list"])
+	}
+}
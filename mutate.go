@@ -0,0 +1,230 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// Set stores v under the given key (see Config.Value for the syntax of
+// keys). Missing intermediate maps are created, e.g. setting
+// "server.tls.cert" creates the maps "server" and "server.tls" if they do
+// not exist. Slice elements can be set by their index, but slices are not
+// extended. An existing value with the key is replaced, including a map
+// with all its nested values.
+//
+// An error is returned if the key crosses a value which is neither a map nor
+// a slice, or if an index is out of range. The configuration is modified in
+// place, so c must not be nil.
+func (c Config) Set(key string, v interface{}) error {
+	if c == nil {
+		return errors.New("cannot set key in nil configuration")
+	}
+	segs, err := parseKey(key)
+	if err != nil {
+		return err
+	}
+	if err := setValue(reflect.ValueOf(c), segs, key, 0, v); err != nil {
+		return fmt.Errorf("cannot set key '%s': %v", key, err)
+	}
+	return nil
+}
+
+// Delete deletes the value with the given key (see Config.Value for the
+// syntax of keys). Deleting a slice element removes it from the slice, so
+// the following elements move up. Maps and slices which become empty by
+// the deletion are deleted as well, e.g. deleting "server.tls.cert" also
+// deletes "server.tls" if "cert" was its only key.
+//
+// An error is returned if the key does not exist, if it crosses a value
+// which is neither a map nor a slice, or if an index is out of range. The
+// configuration is modified in place.
+func (c Config) Delete(key string) error {
+	segs, err := parseKey(key)
+	if err != nil {
+		return err
+	}
+	if _, err := deleteValue(reflect.ValueOf(c), segs, key, 0); err != nil {
+		return fmt.Errorf("cannot delete key '%s': %v", key, err)
+	}
+	return nil
+}
+
+// setValue stores v under the hierarchy given by segs in container, which
+// is a map or a slice. The key is the full key of v, and the key of
+// container ends at the offset parent within key.
+func setValue(container reflect.Value, segs []keySegment, key string, parent int, v interface{}) error {
+	seg := segs[0]
+	elem, setElem, err := element(container, seg, key, parent)
+	if err != nil {
+		return err
+	}
+
+	if len(segs) == 1 {
+		val := reflect.ValueOf(v)
+		if v == nil {
+			val = reflect.Zero(elemType(container))
+		}
+		return setElem(val)
+	}
+
+	child := unwrap(elem)
+	switch {
+	case !child.IsValid() || child.Kind() == reflect.Interface || child.Kind() == reflect.Map && child.IsNil():
+		// missing or nil value
+		m := map[string]interface{}{}
+		if err := setElem(reflect.ValueOf(m)); err != nil {
+			return err
+		}
+		child = reflect.ValueOf(m)
+	case !isContainer(child):
+		return fmt.Errorf("key '%s' is not a map", key[:seg.end])
+	}
+	return setValue(child, segs[1:], key, seg.end, v)
+}
+
+// deleteValue deletes the value with the hierarchy given by segs from
+// container, which is a map or a slice, and returns the updated container.
+// The key is the full key of the value, and the key of container ends at the
+// offset parent within key.
+func deleteValue(container reflect.Value, segs []keySegment, key string, parent int) (reflect.Value, error) {
+	seg := segs[0]
+	elem, setElem, err := element(container, seg, key, parent)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if !elem.IsValid() {
+		return reflect.Value{}, fmt.Errorf("key not found: %s", key)
+	}
+
+	if len(segs) > 1 {
+		child := unwrap(elem)
+		if !isContainer(child) {
+			if child.Kind() == reflect.Interface {
+				// nil value
+				return reflect.Value{}, fmt.Errorf("key not found: %s", key)
+			}
+			return reflect.Value{}, fmt.Errorf("key '%s' is not a map", key[:seg.end])
+		}
+
+		child, err := deleteValue(child, segs[1:], key, seg.end)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if child.Len() > 0 {
+			return container, setElem(child)
+		}
+		// prune the empty parent
+	}
+
+	container = unwrap(container)
+	if container.Kind() == reflect.Map {
+		container.SetMapIndex(mapKey(container, seg.name), reflect.Value{})
+		return container, nil
+	}
+	if container.Kind() == reflect.Array {
+		return reflect.Value{}, fmt.Errorf("cannot remove elements of array '%s'", container.Type())
+	}
+	// copy the remaining elements into a new slice, so aliases of the
+	// original slice are not modified
+	idx := sliceIndex(container, seg.name)
+	res := reflect.MakeSlice(container.Type(), container.Len()-1, container.Len()-1)
+	reflect.Copy(res, container.Slice(0, idx))
+	reflect.Copy(res.Slice(idx, res.Len()), container.Slice(idx+1, container.Len()))
+	return res, nil
+}
+
+// element returns the value of container (a map or a slice) selected by
+// seg, which is invalid if a map does not contain the key, along with a
+// function which replaces it. The key is the full key, in which the key of
+// container ends at the offset parent.
+func element(container reflect.Value, seg keySegment, key string, parent int) (reflect.Value, func(reflect.Value) error, error) {
+	container = unwrap(container)
+	switch container.Kind() {
+	case reflect.Map:
+		if seg.index {
+			return reflect.Value{}, nil, fmt.Errorf("key '%s' is not a slice", key[:parent])
+		}
+		k := mapKey(container, seg.name)
+		if !k.IsValid() {
+			return reflect.Value{}, nil, fmt.Errorf("map key type '%s' is not supported", container.Type().Key())
+		}
+		return container.MapIndex(k), func(v reflect.Value) error {
+			if v.IsValid() && !v.Type().AssignableTo(container.Type().Elem()) {
+				return fmt.Errorf("'%s' could not be assigned to '%s'", v.Type(), container.Type().Elem())
+			}
+			container.SetMapIndex(k, v)
+			return nil
+		}, nil
+
+	case reflect.Slice, reflect.Array:
+		if _, err := strconv.Atoi(seg.name); err != nil {
+			return reflect.Value{}, nil, fmt.Errorf("key '%s' is not a map", key[:parent])
+		}
+		idx := sliceIndex(container, seg.name)
+		if idx < 0 {
+			return reflect.Value{}, nil, fmt.Errorf("index out of range: %s (length %d)", key[:seg.end], container.Len())
+		}
+		elem := container.Index(idx)
+		return elem, func(v reflect.Value) error {
+			if !elem.CanSet() {
+				return fmt.Errorf("element of array '%s' cannot be set", container.Type())
+			}
+			if !v.Type().AssignableTo(elem.Type()) {
+				return fmt.Errorf("'%s' could not be assigned to '%s'", v.Type(), elem.Type())
+			}
+			elem.Set(v)
+			return nil
+		}, nil
+	}
+	return reflect.Value{}, nil, fmt.Errorf("key '%s' is not a map", key[:parent])
+}
+
+// mapKey returns the key of the map m for the given name. If m does not
+// contain a matching key, a new key is returned, which is invalid if the
+// name cannot be converted to the key type of m.
+func mapKey(m reflect.Value, name string) reflect.Value {
+	keyType := m.Type().Key()
+	if keyType.Kind() != reflect.String {
+		for _, k := range m.MapKeys() {
+			if s, ok := keyString(k); ok && s == name {
+				return k
+			}
+		}
+	}
+
+	k := reflect.ValueOf(name)
+	switch {
+	case keyType.Kind() == reflect.String:
+		return k.Convert(keyType)
+	case k.Type().AssignableTo(keyType):
+		return k
+	}
+	return reflect.Value{}
+}
+
+// sliceIndex returns the index of the slice s given by name, or -1 if the
+// index is out of range. Negative indexes count from the end.
+func sliceIndex(s reflect.Value, name string) int {
+	idx, _ := strconv.Atoi(name)
+	if idx < 0 {
+		idx += s.Len()
+	}
+	if idx < 0 || idx >= s.Len() {
+		return -1
+	}
+	return idx
+}
+
+func elemType(container reflect.Value) reflect.Type {
+	return unwrap(container).Type().Elem()
+}
+
+func isContainer(val reflect.Value) bool {
+	switch val.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,182 @@
+package conf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConfigSet(t *testing.T) {
+	c := Config{
+		"server": map[string]interface{}{
+			"port": 80,
+		},
+		"servers": []interface{}{
+			map[string]interface{}{"port": 80},
+			nil,
+		},
+		"hosts": map[interface{}]interface{}{1: "one"},
+		"tags":  []string{"a", "b"},
+	}
+
+	sets := []struct {
+		key string
+		v   interface{}
+	}{
+		{key: "server.tls.cert", v: "cert.pem"},
+		{key: "server.port", v: 8080},
+		{key: "servers[0].port", v: 81},
+		{key: "servers.-1.port", v: 82},
+		{key: `labels."app.io/name"`, v: "web"},
+		{key: "hosts.1", v: "uno"},
+		{key: "hosts.2", v: "two"},
+		{key: "tags[1]", v: "c"},
+		{key: "name", v: nil},
+	}
+	for _, set := range sets {
+		if err := c.Set(set.key, set.v); err != nil {
+			t.Fatalf("unexpected error for key %s: %v", set.key, err)
+		}
+	}
+
+	expected := Config{
+		"server": map[string]interface{}{
+			"port": 8080,
+			"tls":  map[string]interface{}{"cert": "cert.pem"},
+		},
+		"servers": []interface{}{
+			map[string]interface{}{"port": 81},
+			map[string]interface{}{"port": 82},
+		},
+		"labels": map[string]interface{}{"app.io/name": "web"},
+		"hosts":  map[interface{}]interface{}{1: "uno", "2": "two"},
+		"tags":   []string{"a", "c"},
+		"name":   nil,
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Fatalf("unexpected configuration: %v", c)
+	}
+}
+
+func TestConfigSetErrors(t *testing.T) {
+	tests := []struct {
+		key    string
+		v      interface{}
+		errMsg string
+	}{
+		{key: "server.port.number", v: 1, errMsg: "cannot set key 'server.port.number': key 'server.port' is not a map"},
+		{key: "servers[2].port", v: 1, errMsg: "cannot set key 'servers[2].port': index out of range: servers[2] (length 2)"},
+		{key: "servers.name", v: 1, errMsg: "cannot set key 'servers.name': key 'servers' is not a map"},
+		{key: "server[0]", v: 1, errMsg: "cannot set key 'server[0]': key 'server' is not a slice"},
+		{key: "tags[0]", v: 1, errMsg: "cannot set key 'tags[0]': 'int' could not be assigned to 'string'"},
+		{key: "a[", v: 1, errMsg: "invalid key 'a[': missing ']'"},
+	}
+
+	for _, test := range tests {
+		c := Config{
+			"server":  map[string]interface{}{"port": 80},
+			"servers": []interface{}{1, 2},
+			"tags":    []string{"a"},
+		}
+		err := c.Set(test.key, test.v)
+		if err == nil {
+			t.Fatalf("expected error for key %s, got none", test.key)
+		}
+		if err.Error() != test.errMsg {
+			t.Fatalf("unexpected error for key %s: %v", test.key, err)
+		}
+	}
+
+	var c Config
+	if err := c.Set("a", 1); err == nil {
+		t.Fatalf("expected error, got none")
+	}
+}
+
+func TestConfigDelete(t *testing.T) {
+	c := Config{
+		"server": map[string]interface{}{
+			"port": 80,
+			"tls":  map[string]interface{}{"cert": "cert.pem"},
+		},
+		"servers": []interface{}{
+			map[string]interface{}{"port": 80},
+			map[string]interface{}{"port": 81, "host": "b"},
+			map[string]interface{}{"port": 82},
+		},
+		"only": map[string]interface{}{
+			"nested": map[string]interface{}{"value": 1},
+		},
+		"hosts": map[interface{}]interface{}{1: "one", 2: "two"},
+	}
+
+	for _, key := range []string{
+		"server.tls.cert",
+		"servers[0]",
+		"servers[0].host",
+		"servers.-1.port",
+		"only.nested.value",
+		"hosts.1",
+	} {
+		if err := c.Delete(key); err != nil {
+			t.Fatalf("unexpected error for key %s: %v", key, err)
+		}
+	}
+
+	expected := Config{
+		"server": map[string]interface{}{
+			"port": 80,
+		},
+		"servers": []interface{}{
+			map[string]interface{}{"port": 81},
+		},
+		"hosts": map[interface{}]interface{}{2: "two"},
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Fatalf("unexpected configuration: %v", c)
+	}
+}
+
+func TestConfigDeleteSliceAlias(t *testing.T) {
+	list := []interface{}{"a", "b", "c"}
+	c := Config{"list": list}
+
+	if err := c.Delete("list[0]"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(c["list"], []interface{}{"b", "c"}) {
+		t.Fatalf("unexpected list: %v", c["list"])
+	}
+	if !reflect.DeepEqual(list, []interface{}{"a", "b", "c"}) {
+		t.Fatalf("aliased list modified: %v", list)
+	}
+}
+
+func TestConfigDeleteErrors(t *testing.T) {
+	tests := []struct {
+		key    string
+		errMsg string
+	}{
+		{key: "missing", errMsg: "cannot delete key 'missing': key not found: missing"},
+		{key: "server.missing", errMsg: "cannot delete key 'server.missing': key not found: server.missing"},
+		{key: "server.port.number", errMsg: "cannot delete key 'server.port.number': key 'server.port' is not a map"},
+		{key: "nil.value", errMsg: "cannot delete key 'nil.value': key not found: nil.value"},
+		{key: "servers[2]", errMsg: "cannot delete key 'servers[2]': index out of range: servers[2] (length 2)"},
+		{key: "ports[0]", errMsg: "cannot delete key 'ports[0]': cannot remove elements of array '[2]int'"},
+	}
+
+	for _, test := range tests {
+		c := Config{
+			"server":  map[string]interface{}{"port": 80},
+			"servers": []interface{}{1, 2},
+			"ports":   [2]int{80, 81},
+			"nil":     nil,
+		}
+		err := c.Delete(test.key)
+		if err == nil {
+			t.Fatalf("expected error for key %s, got none", test.key)
+		}
+		if err.Error() != test.errMsg {
+			t.Fatalf("unexpected error for key %s: %v", test.key, err)
+		}
+	}
+}
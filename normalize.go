@@ -0,0 +1,91 @@
+package conf
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// Normalized returns an Unmarshaler which parses the data with unmarshal
+// and normalizes the result: all nested maps with keys which are strings,
+// numbers or booleans are converted to map[string]interface{}. Common YAML
+// libraries, for example, produce map[interface{}]interface{} for nested
+// mappings. Such maps can be accessed, decoded and merged without
+// normalization, but normalizing them once avoids converting them again on
+// every access and reports conflicting keys. Normalized can be used with
+// Load as well as RegisterFormat:
+//   conf.RegisterFormat(".yaml", conf.Normalized(yaml.Unmarshal))
+//
+// Map keys of other types cause an error, as well as distinct keys with the
+// same string representation (e.g. 1 and "1").
+func Normalized(unmarshal Unmarshaler) Unmarshaler {
+	return func(data []byte, value interface{}) error {
+		if err := unmarshal(data, value); err != nil {
+			return err
+		}
+
+		switch v := value.(type) {
+		case *Config:
+			return normalizeMap(*v, nil)
+		case *map[string]interface{}:
+			return normalizeMap(*v, nil)
+		}
+		return nil
+	}
+}
+
+// normalizeMap normalizes all values of m in place. The path is the
+// location of m within the configuration.
+func normalizeMap(m map[string]interface{}, path Path) error {
+	for k, v := range m {
+		nv, err := normalize(v, appendKeys(path, k))
+		if err != nil {
+			return err
+		}
+		m[k] = nv
+	}
+	return nil
+}
+
+func normalize(v interface{}, path Path) (interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return v, normalizeMap(v, path)
+
+	case Config:
+		return v, normalizeMap(v, path)
+
+	case []interface{}:
+		for i, elem := range v {
+			nv, err := normalize(elem, appendKeys(path, strconv.Itoa(i)))
+			if err != nil {
+				return nil, err
+			}
+			v[i] = nv
+		}
+		return v, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map {
+		return v, nil
+	}
+
+	m := make(map[string]interface{}, rv.Len())
+	for _, k := range rv.MapKeys() {
+		name, ok := keyString(k)
+		if !ok {
+			return nil, fmt.Errorf("key '%v' of type '%s' in '%s' could not be converted to 'string'", k.Interface(), unwrap(k).Type(), path)
+		}
+		if _, dup := m[name]; dup {
+			return nil, fmt.Errorf("duplicate key '%s' in '%s'", name, path)
+		}
+
+		nv, err := normalize(rv.MapIndex(k).Interface(), appendKeys(path, name))
+		if err != nil {
+			return nil, err
+		}
+		m[name] = nv
+	}
+	return m, nil
+}
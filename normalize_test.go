@@ -0,0 +1,183 @@
+package conf
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// yamlLike unmarshals JSON and converts all nested objects to
+// map[interface{}]interface{}, like common YAML libraries do.
+func yamlLike(data []byte, value interface{}) error {
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+
+	var convert func(v interface{}) interface{}
+	convert = func(v interface{}) interface{} {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			res := make(map[interface{}]interface{}, len(v))
+			for k, elem := range v {
+				res[k] = convert(elem)
+			}
+			return res
+		case []interface{}:
+			for i, elem := range v {
+				v[i] = convert(elem)
+			}
+		}
+		return v
+	}
+
+	c := value.(*Config)
+	for k, v := range m {
+		(*c)[k] = convert(v)
+	}
+	return nil
+}
+
+func TestNonStringKeyedMaps(t *testing.T) {
+	c := Config{
+		"server": map[interface{}]interface{}{
+			"host": "localhost",
+			"Port": 8080,
+			"tls": map[interface{}]interface{}{
+				"enabled": true,
+			},
+		},
+		"codes": map[interface{}]interface{}{
+			404:  "not found",
+			true: "yes",
+			1.5:  "float",
+		},
+		"ports": map[int]string{80: "http"},
+		"servers": []interface{}{
+			map[interface{}]interface{}{"port": 81},
+		},
+	}
+
+	tests := map[string]string{
+		"server.host":        "localhost",
+		"server.tls.enabled": "true",
+		"codes.404":          "not found",
+		"codes.true":         "yes",
+		"codes.1.5":          "",
+		`codes."1.5"`:        "float",
+		"ports.80":           "http",
+		"servers[0].port":    "81",
+	}
+	for key, expected := range tests {
+		v, err := c.Value(key)
+		if expected == "" {
+			if err == nil {
+				t.Fatalf("expected error for key %s, got none", key)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("unexpected error for key %s: %v", key, err)
+		}
+		if s, _ := v.String(); s != expected {
+			t.Fatalf("unexpected value for key %s: %s", key, s)
+		}
+	}
+
+	var server struct {
+		Host string
+		Port int
+		TLS  struct {
+			Enabled bool
+		} `config:"tls"`
+	}
+	if err := c.Decode("server", &server); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if server.Host != "localhost" || server.Port != 8080 || !server.TLS.Enabled {
+		t.Fatalf("unexpected server: %+v", server)
+	}
+
+	var codes struct {
+		NotFound string `config:"404"`
+	}
+	if err := c.Decode("codes", &codes); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if codes.NotFound != "not found" {
+		t.Fatalf("unexpected codes: %+v", codes)
+	}
+
+	matches, err := c.Query("codes.*")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(matches) != 3 {
+		t.Fatalf("unexpected matches: %v", matches)
+	}
+
+	var s struct{}
+	if err := (Config{"m": map[[2]int]int{}}).Decode("m", &s); err == nil {
+		t.Fatalf("expected error, got none")
+	}
+}
+
+func TestNormalized(t *testing.T) {
+	data := `{ "server": { "host": "localhost", "tls": { "enabled": true } }, "list": [ { "a": 1 } ] }`
+
+	c, err := Load(strings.NewReader(data), Normalized(yamlLike))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	server, ok := c["server"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("unexpected server type: %T", c["server"])
+	}
+	if _, ok := server["tls"].(map[string]interface{}); !ok {
+		t.Fatalf("unexpected tls type: %T", server["tls"])
+	}
+	if _, ok := c["list"].([]interface{})[0].(map[string]interface{}); !ok {
+		t.Fatalf("unexpected list element type: %T", c["list"].([]interface{})[0])
+	}
+
+	merged := Merge(c, Config{"server": map[string]interface{}{"port": 80}})
+	if v, err := merged.Value("server.host"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if s, _ := v.String(); s != "localhost" {
+		t.Fatalf("unexpected host: %s", s)
+	}
+
+	if _, err := Load(strings.NewReader("{"), Normalized(yamlLike)); err == nil {
+		t.Fatalf("expected error, got none")
+	}
+}
+
+func TestNormalizeErrors(t *testing.T) {
+	tests := []struct {
+		config Config
+		errMsg string
+	}{
+		{
+			config: Config{"a": map[interface{}]interface{}{1: "x", "1": "y"}},
+			errMsg: "duplicate key '1' in 'a'",
+		},
+		{
+			config: Config{"a": []interface{}{map[interface{}]interface{}{[2]int{}: "x"}}},
+			errMsg: "key '[0 0]' of type '[2]int' in 'a.0' could not be converted to 'string'",
+		},
+	}
+
+	for _, test := range tests {
+		unmarshal := Normalized(func(data []byte, value interface{}) error {
+			*value.(*Config) = test.config
+			return nil
+		})
+		_, err := Load(strings.NewReader(""), unmarshal)
+		if err == nil {
+			t.Fatalf("expected error for %v, got none", test.config)
+		}
+		if err.Error() != test.errMsg {
+			t.Fatalf("unexpected error for %v: %v", test.config, err)
+		}
+	}
+}
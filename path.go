@@ -0,0 +1,141 @@
+package conf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Path represents a configuration key as a sequence of segments. Each
+// segment is a map key or, for slices and arrays, an index. Unlike a key
+// string, the segments may contain arbitrary characters, so a Path is the
+// safe way to build keys from map keys like host names:
+//   key := conf.Path{"hosts", "example.com", "port"}.String()
+//   // key == `hosts."example.com".port`
+//   v, err := c.Value(key)
+type Path []string
+
+// ParsePath parses the configuration key into its segments. See
+// Config.Value for the syntax of keys.
+func ParsePath(key string) (Path, error) {
+	segs, err := parseKey(key)
+	if err != nil {
+		return nil, err
+	}
+	p := make(Path, len(segs))
+	for i, seg := range segs {
+		p[i] = seg.name
+	}
+	return p, nil
+}
+
+// String returns the configuration key of the path. Segments containing
+// dots, brackets, quotes or backslashes, as well as empty segments, are
+// quoted.
+func (p Path) String() string {
+	var sb strings.Builder
+	for i, seg := range p {
+		if i > 0 {
+			sb.WriteByte('.')
+		}
+		if seg != "" && strings.IndexAny(seg, `.[]"\`) < 0 {
+			sb.WriteString(seg)
+			continue
+		}
+
+		sb.WriteByte('"')
+		for j := 0; j < len(seg); j++ {
+			if seg[j] == '"' || seg[j] == '\\' {
+				sb.WriteByte('\\')
+			}
+			sb.WriteByte(seg[j])
+		}
+		sb.WriteByte('"')
+	}
+	return sb.String()
+}
+
+// keySegment is a single segment of a configuration key. A segment is
+// either a name, which is looked up in a map or used as index of a slice if
+// it is an integer, or an index in brackets, which requires a slice.
+type keySegment struct {
+	name   string
+	index  bool // segment is an index in brackets
+	quoted bool // segment is quoted
+	end    int  // end of the segment within the key
+}
+
+// parseKey splits the configuration key into its segments. The segments
+// are separated by dots, and each segment may be followed by indexes in
+// brackets, e.g. "servers[0].port" or "matrix[1][-1]". Segments may be
+// quoted (e.g. `hosts."example.com"`), and a backslash escapes the next
+// character both in quoted and unquoted segments.
+func parseKey(key string) ([]keySegment, error) {
+	var segs []keySegment
+	pos := 0
+	for {
+		start := pos
+		name, quoted := "", false
+		if pos < len(key) && key[pos] == '"' {
+			var err error
+			if name, pos, err = parseQuotedSegment(key, pos); err != nil {
+				return nil, err
+			}
+			quoted = true
+		} else {
+			var sb strings.Builder
+			for ; pos < len(key) && key[pos] != '.' && key[pos] != '['; pos++ {
+				if key[pos] == '\\' && pos+1 < len(key) {
+					pos++
+				}
+				sb.WriteByte(key[pos])
+			}
+			name = sb.String()
+		}
+		if quoted || pos > start || pos == len(key) || key[pos] == '.' {
+			segs = append(segs, keySegment{name: name, quoted: quoted, end: pos})
+		}
+
+		for pos < len(key) && key[pos] == '[' {
+			end := pos + 1
+			for end < len(key) && key[end] != ']' {
+				end++
+			}
+			if end == len(key) {
+				return nil, fmt.Errorf("invalid key '%s': missing ']'", key)
+			}
+			idx := key[pos+1 : end]
+			if _, err := strconv.Atoi(idx); err != nil {
+				return nil, fmt.Errorf("invalid key '%s': invalid index '%s'", key, idx)
+			}
+			pos = end + 1
+			segs = append(segs, keySegment{name: idx, index: true, end: pos})
+		}
+
+		switch {
+		case pos == len(key):
+			return segs, nil
+		case key[pos] != '.':
+			return nil, fmt.Errorf("invalid key '%s': unexpected '%c' at position %d", key, key[pos], pos)
+		}
+		pos++ // '.'
+	}
+}
+
+// parseQuotedSegment parses the quoted segment starting at pos and returns
+// its name along with the position following the closing quote.
+func parseQuotedSegment(key string, pos int) (string, int, error) {
+	var sb strings.Builder
+	for pos++; pos < len(key); pos++ {
+		switch key[pos] {
+		case '"':
+			return sb.String(), pos + 1, nil
+		case '\\':
+			if pos+1 < len(key) {
+				pos++
+			}
+		}
+		sb.WriteByte(key[pos])
+	}
+	return "", 0, fmt.Errorf("invalid key '%s': missing '\"'", key)
+}
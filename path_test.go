@@ -0,0 +1,114 @@
+package conf
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := map[string]Path{
+		"foo":                             {"foo"},
+		"foo.bar":                         {"foo", "bar"},
+		`hosts."example.com".port`:        {"hosts", "example.com", "port"},
+		`hosts.example\.com.port`:         {"hosts", "example.com", "port"},
+		`labels."app.kubernetes.io/name"`: {"labels", "app.kubernetes.io/name"},
+		`"a\"b\\c"`:                       {`a"b\c`},
+		`a\[0\]`:                          {"a[0]"},
+		`servers[0]."port"`:               {"servers", "0", "port"},
+		`"a.b"[1][-1]`:                    {"a.b", "1", "-1"},
+		`a."".b`:                          {"a", "", "b"},
+		"a..b":                            {"a", "", "b"},
+	}
+	for key, expected := range tests {
+		p, err := ParsePath(key)
+		if err != nil {
+			t.Fatalf("unexpected error for key %s: %v", key, err)
+		}
+		if !reflect.DeepEqual(p, expected) {
+			t.Fatalf("unexpected path for key %s: %q", key, p)
+		}
+	}
+
+	errs := map[string]string{
+		`a."b`:   `invalid key 'a."b': missing '"'`,
+		`a."b"c`: `invalid key 'a."b"c': unexpected 'c' at position 5`,
+		`a[0`:    `invalid key 'a[0': missing ']'`,
+		`a[x]`:   `invalid key 'a[x]': invalid index 'x'`,
+	}
+	for key, expected := range errs {
+		_, err := ParsePath(key)
+		if err == nil {
+			t.Fatalf("expected error for key %s, got none", key)
+		}
+		if err.Error() != expected {
+			t.Fatalf("unexpected error for key %s: %v", key, err)
+		}
+	}
+}
+
+func TestPathString(t *testing.T) {
+	tests := []struct {
+		path Path
+		key  string
+	}{
+		{path: Path{"foo", "bar"}, key: "foo.bar"},
+		{path: Path{"hosts", "example.com", "port"}, key: `hosts."example.com".port`},
+		{path: Path{`a"b\c`, "d[0]"}, key: `"a\"b\\c"."d[0]"`},
+		{path: Path{"a", "", "b"}, key: `a."".b`},
+		{path: Path{"servers", "0"}, key: "servers.0"},
+	}
+	for _, test := range tests {
+		key := test.path.String()
+		if key != test.key {
+			t.Fatalf("unexpected key for %q: %s", test.path, key)
+		}
+		p, err := ParsePath(key)
+		if err != nil {
+			t.Fatalf("unexpected error for key %s: %v", key, err)
+		}
+		if !reflect.DeepEqual(p, test.path) {
+			t.Fatalf("unexpected path for key %s: %q", key, p)
+		}
+	}
+}
+
+func TestConfigValueQuoted(t *testing.T) {
+	c := Config{
+		"hosts": map[string]interface{}{
+			"example.com": map[string]interface{}{"port": 443},
+		},
+		"labels": map[string]interface{}{
+			"app.kubernetes.io/name": "web",
+		},
+	}
+
+	for _, key := range []string{
+		`hosts."example.com".port`,
+		`hosts.example\.com.port`,
+		Path{"hosts", "example.com", "port"}.String(),
+	} {
+		var port int
+		if err := c.Decode(key, &port); err != nil {
+			t.Fatalf("unexpected error for key %s: %v", key, err)
+		}
+		if port != 443 {
+			t.Fatalf("unexpected port for key %s: %d", key, port)
+		}
+	}
+
+	if _, err := c.Value("hosts.example.com.port"); err == nil {
+		t.Fatalf("expected error, got none")
+	}
+
+	var l Layers
+	l.Add("defaults", c)
+	var buf bytes.Buffer
+	if err := l.Explain(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), `labels."app.kubernetes.io/name" = web (defaults)`) {
+		t.Fatalf("unexpected explanation: %s", buf.String())
+	}
+}
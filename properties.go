@@ -0,0 +1,202 @@
+package conf
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Properties parses the Java properties encoded data and stores the result
+// in value. It can be passed to Load or registered with RegisterFormat.
+//
+// Each logical line contains a key and a value separated by '=', ':' or
+// whitespace. Lines starting with '#' or '!' are comments. A backslash at
+// the end of a line continues the logical line on the next line, ignoring
+// the leading whitespace of the following line. Keys and values support the
+// escape sequences \t, \n, \r, \f, \uXXXX and the escaping of any other
+// character (e.g. "\=" or "\:"). Keys are split at their dots and stored as
+// nested maps, so the value of "a.b.c=value" is accessible via "a.b.c". If
+// a key is defined multiple times the last definition wins. If a key is
+// defined with a value as well as with sub-keys, as is common for JVM tools
+// (e.g. "log4j.appender.A1=..." and "log4j.appender.A1.layout=..."), the
+// value is stored under the empty sub-key, i.e. it is accessible via the key
+// `log4j.appender.A1.""` (see Config.Value). All values are stored as
+// strings. Syntax errors are reported as *SyntaxError.
+func Properties(data []byte, value interface{}) error {
+	m, err := parseProperties(string(data), nil)
+	if err != nil {
+		return err
+	}
+	return storeMap(m, value)
+}
+
+type propertiesParser struct {
+	data string
+	pos  int
+}
+
+// parseProperties parses the Java properties encoded data. If lines is not
+// nil, the line of each key is stored in it.
+func parseProperties(data string, lines map[string]int) (map[string]interface{}, error) {
+	p := &propertiesParser{data: data}
+	lc := lineCounter{data: data}
+
+	m := make(map[string]interface{})
+	for {
+		p.skip(" \t\f\r\n")
+		if p.eof() {
+			return m, nil
+		}
+		if c := p.peek(); c == '#' || c == '!' {
+			for !p.eof() && p.peek() != '\n' && p.peek() != '\r' {
+				p.pos++
+			}
+			continue
+		}
+
+		keyPos := p.pos
+		key, err := p.parseElement(true)
+		if err != nil {
+			return nil, err
+		}
+
+		// separator: whitespace with an optional '=' or ':'
+		p.skip(" \t\f")
+		if !p.eof() && (p.peek() == '=' || p.peek() == ':') {
+			p.pos++
+			p.skip(" \t\f")
+		}
+
+		val, err := p.parseElement(false)
+		if err != nil {
+			return nil, err
+		}
+
+		keys := strings.Split(key, ".")
+		for _, k := range keys {
+			if k == "" {
+				return nil, p.errorAt(keyPos, "invalid key '%s'", key)
+			}
+		}
+		insertProperty(m, keys, val)
+		if lines != nil {
+			lines[key] = lc.lineAt(keyPos)
+		}
+	}
+}
+
+// insertProperty inserts the value v with the given keys into m. Unlike
+// insert it never fails: if a key has a value as well as sub-keys, the value
+// is moved to the empty sub-key.
+func insertProperty(m map[string]interface{}, keys []string, v string) {
+	for _, key := range keys[:len(keys)-1] {
+		next, isMap := m[key].(map[string]interface{})
+		if !isMap {
+			next = make(map[string]interface{})
+			if leaf, has := m[key]; has {
+				next[""] = leaf
+			}
+			m[key] = next
+		}
+		m = next
+	}
+
+	key := keys[len(keys)-1]
+	if sub, isMap := m[key].(map[string]interface{}); isMap {
+		sub[""] = v
+		return
+	}
+	m[key] = v
+}
+
+// parseElement parses a key or a value of a logical line. Keys are
+// terminated by an unescaped separator, values by the end of the line.
+func (p *propertiesParser) parseElement(isKey bool) (string, error) {
+	var sb strings.Builder
+	for !p.eof() {
+		c := p.peek()
+		switch {
+		case c == '\n' || c == '\r':
+			return sb.String(), nil
+
+		case isKey && strings.IndexByte("=: \t\f", c) >= 0:
+			return sb.String(), nil
+
+		case c == '\\':
+			if err := p.parseEscape(&sb); err != nil {
+				return "", err
+			}
+
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+	return sb.String(), nil
+}
+
+func (p *propertiesParser) parseEscape(sb *strings.Builder) error {
+	start := p.pos
+	p.pos++ // '\\'
+	if p.eof() {
+		return nil
+	}
+
+	c := p.peek()
+	p.pos++
+	switch c {
+	case '\r', '\n':
+		// line continuation
+		if c == '\r' && !p.eof() && p.peek() == '\n' {
+			p.pos++
+		}
+		p.skip(" \t\f")
+	case 't':
+		sb.WriteByte('\t')
+	case 'n':
+		sb.WriteByte('\n')
+	case 'r':
+		sb.WriteByte('\r')
+	case 'f':
+		sb.WriteByte('\f')
+	case 'u':
+		if p.pos+4 > len(p.data) {
+			return p.errorAt(start, "invalid unicode escape")
+		}
+		code, err := strconv.ParseUint(p.data[p.pos:p.pos+4], 16, 16)
+		if err != nil {
+			return p.errorAt(start, "invalid unicode escape '%s'", p.data[start:p.pos+4])
+		}
+		p.pos += 4
+
+		r := rune(code)
+		if r >= 0xd800 && r < 0xdc00 && strings.HasPrefix(p.data[p.pos:], `\u`) && p.pos+6 <= len(p.data) {
+			// surrogate pair
+			if low, err := strconv.ParseUint(p.data[p.pos+2:p.pos+6], 16, 16); err == nil && low >= 0xdc00 && low < 0xe000 {
+				r = (r-0xd800)<<10 + (rune(low) - 0xdc00) + 0x10000
+				p.pos += 6
+			}
+		}
+		sb.WriteRune(r)
+	default:
+		sb.WriteByte(c)
+	}
+	return nil
+}
+
+func (p *propertiesParser) skip(chars string) {
+	for !p.eof() && strings.IndexByte(chars, p.peek()) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *propertiesParser) peek() byte {
+	return p.data[p.pos]
+}
+
+func (p *propertiesParser) eof() bool {
+	return p.pos >= len(p.data)
+}
+
+func (p *propertiesParser) errorAt(pos int, msg string, args ...interface{}) error {
+	return syntaxError("properties", p.data, pos, msg, args...)
+}
@@ -0,0 +1,107 @@
+package conf
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestProperties(t *testing.T) {
+	c, err := Load(bytes.NewBufferString(`
+# comment
+! another comment
+app.name = myapp
+app.server.address:192.168.1.7
+app.server.port 8080
+app.description = a long \
+                  description
+app.greeting = gr\u00fc\u00DF dich\tand \ud83d\ude00
+app.path = C:\\temp
+key\ with\ spaces = escaped
+app.empty =
+app.name = overwritten
+`), Properties)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := Config{
+		"app": map[string]interface{}{
+			"name":        "overwritten",
+			"description": "a long description",
+			"greeting":    "grüß dich\tand \U0001F600",
+			"path":        `C:\temp`,
+			"empty":       "",
+			"server": map[string]interface{}{
+				"address": "192.168.1.7",
+				"port":    "8080",
+			},
+		},
+		"key with spaces": "escaped",
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Fatalf("unexpected configuration: %#v", c)
+	}
+
+	v, err := c.Value("app.server.port")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if port, err := v.Int(); err != nil || port != 8080 {
+		t.Fatalf("unexpected port: %v (%v)", port, err)
+	}
+}
+
+func TestPropertiesValuesWithSubKeys(t *testing.T) {
+	c, err := Load(bytes.NewBufferString(`
+log4j.rootLogger=DEBUG, A1
+log4j.appender.A1=org.apache.log4j.ConsoleAppender
+log4j.appender.A1.layout=org.apache.log4j.PatternLayout
+log4j.appender.A1.layout.ConversionPattern=%-4r [%t] %-5p %c - %m%n
+log4j.logger.com.foo.bar=WARN
+log4j.logger.com.foo=INFO
+`), Properties)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		`log4j.rootLogger`:                           "DEBUG, A1",
+		`log4j.appender.A1.""`:                       "org.apache.log4j.ConsoleAppender",
+		`log4j.appender.A1.layout.""`:                "org.apache.log4j.PatternLayout",
+		`log4j.appender.A1.layout.ConversionPattern`: "%-4r [%t] %-5p %c - %m%n",
+		`log4j.logger.com.foo.bar`:                   "WARN",
+		`log4j.logger.com.foo.""`:                    "INFO",
+	}
+	for key, exp := range expected {
+		v, err := c.Value(key)
+		if err != nil {
+			t.Fatalf("unexpected error for key %s: %v", key, err)
+		}
+		if s, _ := v.String(); s != exp {
+			t.Fatalf("unexpected value for key %s: %s", key, s)
+		}
+	}
+}
+
+func TestPropertiesErrors(t *testing.T) {
+	invalid := []struct {
+		input  string
+		line   int
+		column int
+	}{
+		{"a..b=1", 1, 1},
+		{"a=\\u00g1", 1, 3},
+	}
+
+	for _, test := range invalid {
+		_, err := Load(bytes.NewBufferString(test.input), Properties)
+		serr, ok := err.(*SyntaxError)
+		if !ok {
+			t.Fatalf("expected syntax error for %q, got %v", test.input, err)
+		}
+		if serr.Format != "properties" || serr.Line != test.line || serr.Column != test.column {
+			t.Fatalf("unexpected error for %q: %v", test.input, err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package conf
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// Match is a configuration value found by Config.Query.
+type Match struct {
+	Path  Path   // concrete path of the value
+	Value *Value // matched value
+}
+
+// Query returns all configuration values matching the given pattern. The
+// pattern is a key as described for Config.Value, in which a segment "*"
+// matches any single map key or slice index, and a segment "**" matches any
+// number of levels (including none). For example "backends.*.timeout"
+// matches the timeout of every backend, whether backends is a map or a
+// slice, and "**.timeout" matches every timeout at any depth. Quoted
+// segments (`"*"`) are matched literally.
+//
+// The matches are returned in depth-first order, i.e. matches at a level
+// precede the matches nested below it, map keys are visited alphabetically
+// and slice elements by their index. Each value is returned only once,
+// even if it is matched in several ways. If nothing matches an empty result
+// is returned; an error is only returned if the pattern is invalid.
+func (c Config) Query(pattern string) ([]Match, error) {
+	segs, err := parseKey(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &query{seen: make(map[string]struct{})}
+	q.match(reflect.ValueOf(c), nil, segs)
+	return q.matches, nil
+}
+
+type query struct {
+	matches []Match
+	seen    map[string]struct{}
+}
+
+func (q *query) match(val reflect.Value, path Path, segs []keySegment) {
+	if len(segs) == 0 {
+		key := path.String()
+		if _, dup := q.seen[key]; !dup {
+			q.seen[key] = struct{}{}
+			v := Value(val)
+			q.matches = append(q.matches, Match{
+				Path:  path,
+				Value: &v,
+			})
+		}
+		return
+	}
+
+	seg := segs[0]
+	switch {
+	case isWildcard(seg, "*"):
+		eachChild(val, func(name string, elem reflect.Value) {
+			q.match(elem, appendKeys(path, name), segs[1:])
+		})
+
+	case isWildcard(seg, "**"):
+		q.match(val, path, segs[1:])
+		eachChild(val, func(name string, elem reflect.Value) {
+			q.match(elem, appendKeys(path, name), segs)
+		})
+
+	default:
+		elem, _ := child(val, seg)
+		if !elem.IsValid() {
+			return
+		}
+		name := seg.name
+		if v := unwrap(val); v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+			// use the actual index in the path
+			idx, _ := strconv.Atoi(name)
+			if idx < 0 {
+				idx += v.Len()
+			}
+			name = strconv.Itoa(idx)
+		}
+		q.match(elem, appendKeys(path, name), segs[1:])
+	}
+}
+
+func isWildcard(seg keySegment, wildcard string) bool {
+	return !seg.quoted && !seg.index && seg.name == wildcard
+}
+
+// eachChild calls fn for each map value and slice element of val. Map
+// values are visited in the alphabetical order of their keys. Map keys which
+// cannot be represented as strings are skipped (see keyString).
+func eachChild(val reflect.Value, fn func(name string, elem reflect.Value)) {
+	val = unwrap(val)
+	switch val.Kind() {
+	case reflect.Map:
+		names := make([]string, 0, val.Len())
+		keys := make(map[string]reflect.Value, val.Len())
+		for _, k := range val.MapKeys() {
+			if name, ok := keyString(k); ok {
+				names = append(names, name)
+				keys[name] = k
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fn(name, val.MapIndex(keys[name]))
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < val.Len(); i++ {
+			fn(strconv.Itoa(i), val.Index(i))
+		}
+	}
+}
+
+// unwrap returns the value contained in the interface value val.
+func unwrap(val reflect.Value) reflect.Value {
+	if val.Kind() == reflect.Interface && !val.IsNil() {
+		return val.Elem()
+	}
+	return val
+}
@@ -0,0 +1,109 @@
+package conf
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestConfigQuery(t *testing.T) {
+	c := Config{
+		"backends": map[string]interface{}{
+			"b": map[string]interface{}{"timeout": "2s", "host": "b"},
+			"a": map[string]interface{}{"timeout": "1s", "host": "a"},
+			"c": map[string]interface{}{"host": "c"},
+		},
+		"servers": []interface{}{
+			map[string]interface{}{"port": 80, "tls": map[string]interface{}{"timeout": "3s"}},
+			map[string]interface{}{"port": 81},
+		},
+		"example.com": map[string]interface{}{"*": "literal"},
+		"timeout":     "4s",
+	}
+
+	tests := []struct {
+		pattern string
+		paths   []Path
+	}{
+		{
+			pattern: "backends.*.timeout",
+			paths:   []Path{{"backends", "a", "timeout"}, {"backends", "b", "timeout"}},
+		},
+		{
+			pattern: "servers.*.port",
+			paths:   []Path{{"servers", "0", "port"}, {"servers", "1", "port"}},
+		},
+		{
+			pattern: "servers[-1].port",
+			paths:   []Path{{"servers", "1", "port"}},
+		},
+		{
+			pattern: "**.timeout",
+			paths: []Path{
+				{"timeout"},
+				{"backends", "a", "timeout"},
+				{"backends", "b", "timeout"},
+				{"servers", "0", "tls", "timeout"},
+			},
+		},
+		{
+			pattern: "servers.**.timeout",
+			paths:   []Path{{"servers", "0", "tls", "timeout"}},
+		},
+		{
+			pattern: "**.**.timeout",
+			paths: []Path{
+				{"timeout"},
+				{"backends", "a", "timeout"},
+				{"backends", "b", "timeout"},
+				{"servers", "0", "tls", "timeout"},
+			},
+		},
+		{
+			pattern: `"example.com"."*"`,
+			paths:   []Path{{"example.com", "*"}},
+		},
+		{
+			pattern: "*.missing",
+			paths:   nil,
+		},
+		{
+			pattern: "servers[5]",
+			paths:   nil,
+		},
+	}
+
+	for _, test := range tests {
+		matches, err := c.Query(test.pattern)
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", test.pattern, err)
+		}
+		var paths []Path
+		for _, m := range matches {
+			paths = append(paths, m.Path)
+		}
+		if !reflect.DeepEqual(paths, test.paths) {
+			t.Fatalf("unexpected matches for %s: %q", test.pattern, paths)
+		}
+	}
+
+	matches, err := c.Query("backends.*.timeout")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var timeouts []time.Duration
+	for _, m := range matches {
+		var d time.Duration
+		if err := m.Value.Decode(&d); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		timeouts = append(timeouts, d)
+	}
+	if !reflect.DeepEqual(timeouts, []time.Duration{time.Second, 2 * time.Second}) {
+		t.Fatalf("unexpected timeouts: %v", timeouts)
+	}
+
+	if _, err := c.Query("servers[*]"); err == nil {
+		t.Fatalf("expected error, got none")
+	}
+}
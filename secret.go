@@ -0,0 +1,197 @@
+package conf
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SecretScheme is the prefix of string values which refer to a secret.
+// A secret reference has the form "secret://provider/ref", where provider
+// is the name of a registered SecretProvider and ref is passed to it.
+const SecretScheme = "secret://"
+
+const secretMask = "******"
+
+// Secret represents a sensitive configuration value, e.g. a password. The
+// String, GoString, MarshalText and MarshalJSON methods mask the value, so
+// it does not appear in logs or configuration dumps. The actual value can be
+// obtained by a conversion to string or by decoding it into a string.
+type Secret string
+
+// String returns a mask instead of the secret value.
+func (s Secret) String() string {
+	return secretMask
+}
+
+// GoString returns a mask instead of the secret value.
+func (s Secret) GoString() string {
+	return strconv.Quote(secretMask)
+}
+
+// MarshalText returns a mask instead of the secret value.
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(secretMask), nil
+}
+
+// MarshalJSON returns a mask instead of the secret value.
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(secretMask)), nil
+}
+
+var secretType = reflect.TypeOf(Secret(""))
+
+// SecretProvider resolves secret references (see SecretScheme).
+type SecretProvider interface {
+	// Secret returns the secret value for the given reference. The
+	// reference is the part following "secret://provider/".
+	Secret(ref string) (string, error)
+}
+
+// SecretProviderFunc is an adapter to use an ordinary function as a
+// SecretProvider.
+type SecretProviderFunc func(ref string) (string, error)
+
+// Secret calls fn(ref).
+func (fn SecretProviderFunc) Secret(ref string) (string, error) {
+	return fn(ref)
+}
+
+var secretProviders = struct {
+	sync.RWMutex
+	providers map[string]SecretProvider
+}{
+	providers: map[string]SecretProvider{
+		"file": SecretProviderFunc(fileSecret),
+		"env":  SecretProviderFunc(envSecret),
+	},
+}
+
+// RegisterSecretProvider registers p as the provider for all secret
+// references of the form "secret://name/...". A previously registered
+// provider with the same name will be replaced. If p is nil the provider
+// will be unregistered.
+//
+// The providers "file" and "env" are registered by default. The file
+// provider reads the secret from the file with the absolute path following
+// the provider name, e.g. "secret://file/run/secrets/db_password" reads the
+// file "/run/secrets/db_password". A single trailing newline is removed
+// from the file's contents. The env provider reads the secret from an
+// environment variable, e.g. "secret://env/DB_PASS" reads the variable
+// DB_PASS. It is an error if the variable is not set.
+func RegisterSecretProvider(name string, p SecretProvider) {
+	secretProviders.Lock()
+	defer secretProviders.Unlock()
+
+	if p == nil {
+		delete(secretProviders.providers, name)
+	} else {
+		secretProviders.providers[name] = p
+	}
+}
+
+// ResolveSecrets returns a copy of the configuration in which all string
+// values which refer to a secret (see SecretScheme) are replaced by the
+// secret value of type Secret. This has to be done before decoding the
+// configuration: decoding an unresolved reference into a string (or a
+// Secret) is an error, so a reference is never mistaken for the secret
+// value. Decoding a Secret into a string yields the actual secret value,
+// decoding it into an interface{} retains the Secret type.
+//
+// An error is returned if a reference names an unregistered provider or if
+// a provider fails. The error names the key of the reference, but it never
+// contains secret values. The configuration c is not modified.
+func (c Config) ResolveSecrets() (Config, error) {
+	res := Merge(c) // deep copy
+	if _, err := resolveSecrets(nil, map[string]interface{}(res)); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// resolveSecrets resolves all secret references in v, which is located at
+// the given path, and returns the result. Maps and slices are modified in
+// place.
+func resolveSecrets(path []string, v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		if !strings.HasPrefix(v, SecretScheme) {
+			return v, nil
+		}
+		s, err := lookupSecret(v[len(SecretScheme):])
+		if err != nil {
+			return nil, fmt.Errorf("cannot resolve secret of key '%s': %v", Path(path).String(), err)
+		}
+		return s, nil
+
+	case map[string]interface{}:
+		for k, elem := range v {
+			r, err := resolveSecrets(appendKeys(path, k), elem)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = r
+		}
+
+	case []interface{}:
+		for i, elem := range v {
+			r, err := resolveSecrets(appendKeys(path, strconv.Itoa(i)), elem)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = r
+		}
+	}
+	return v, nil
+}
+
+func lookupSecret(uri string) (Secret, error) {
+	i := strings.IndexByte(uri, '/')
+	if i <= 0 {
+		return "", fmt.Errorf("invalid secret reference '%s%s'", SecretScheme, uri)
+	}
+	name, ref := uri[:i], uri[i+1:]
+
+	secretProviders.RLock()
+	p := secretProviders.providers[name]
+	secretProviders.RUnlock()
+	if p == nil {
+		return "", fmt.Errorf("unknown secret provider '%s'", name)
+	}
+
+	s, err := p.Secret(ref)
+	if err != nil {
+		return "", err
+	}
+	return Secret(s), nil
+}
+
+func fileSecret(ref string) (string, error) {
+	data, err := ioutil.ReadFile("/" + ref)
+	if err != nil {
+		return "", err
+	}
+	s := strings.TrimSuffix(string(data), "\n")
+	return strings.TrimSuffix(s, "\r"), nil
+}
+
+func envSecret(ref string) (string, error) {
+	s, has := os.LookupEnv(ref)
+	if !has {
+		return "", fmt.Errorf("environment variable '%s' is not set", ref)
+	}
+	return s, nil
+}
+
+// displayString returns the string of v for error messages. Secret values
+// are masked.
+func displayString(v reflect.Value) string {
+	if v.Type() == secretType {
+		return secretMask
+	}
+	return v.String()
+}
@@ -0,0 +1,188 @@
+package conf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigResolveSecrets(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTestFile(t, dir, "db_password", "file-secret\n")
+	t.Setenv("CONF_TEST_SECRET", "env-secret")
+
+	RegisterSecretProvider("vault", SecretProviderFunc(func(ref string) (string, error) {
+		return "vault:" + ref, nil
+	}))
+	defer RegisterSecretProvider("vault", nil)
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c := Config{
+		"db": map[string]interface{}{
+			"user":     "admin",
+			"password": "secret://file" + filepath.ToSlash(abs),
+		},
+		"token": "secret://env/CONF_TEST_SECRET",
+		"keys":  []interface{}{"secret://vault/kv/key"},
+	}
+
+	res, err := c.ResolveSecrets()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var db struct {
+		User     string
+		Password string
+	}
+	if err := res.Decode("db", &db); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if db.User != "admin" || db.Password != "file-secret" {
+		t.Fatalf("unexpected db config: %+v", db)
+	}
+
+	var token Secret
+	if err := res.Decode("token", &token); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(token) != "env-secret" {
+		t.Fatalf("unexpected token: %s", string(token))
+	}
+	if keys := res["keys"].([]interface{}); keys[0] != Secret("vault:kv/key") {
+		t.Fatalf("unexpected keys: %#v", keys)
+	}
+
+	// the original configuration is not modified
+	if c["token"] != "secret://env/CONF_TEST_SECRET" {
+		t.Fatalf("original configuration modified")
+	}
+
+	// secret values never appear in dumps
+	dumps := []string{
+		fmt.Sprint(res),
+		fmt.Sprintf("%+v", res),
+		fmt.Sprintf("%#v", res),
+	}
+	data, err := json.Marshal(res)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	dumps = append(dumps, string(data))
+	for _, dump := range dumps {
+		if strings.Contains(dump, "secret") {
+			t.Fatalf("secret value in dump: %s", dump)
+		}
+	}
+}
+
+func TestConfigResolveSecretsErrors(t *testing.T) {
+	unsetEnv(t, "CONF_TEST_UNSET")
+
+	RegisterSecretProvider("failing", SecretProviderFunc(func(ref string) (string, error) {
+		return "", errors.New("provider failed")
+	}))
+	defer RegisterSecretProvider("failing", nil)
+
+	tests := []struct {
+		config Config
+		errMsg string
+	}{
+		{
+			config: Config{"a": map[string]interface{}{"b": "secret://env/CONF_TEST_UNSET"}},
+			errMsg: "cannot resolve secret of key 'a.b': environment variable 'CONF_TEST_UNSET' is not set",
+		},
+		{
+			config: Config{"a": "secret://unknown/x"},
+			errMsg: "cannot resolve secret of key 'a': unknown secret provider 'unknown'",
+		},
+		{
+			config: Config{"a": "secret://env"},
+			errMsg: "cannot resolve secret of key 'a': invalid secret reference 'secret://env'",
+		},
+		{
+			config: Config{"a": []interface{}{"secret://failing/x"}},
+			errMsg: "cannot resolve secret of key 'a.0': provider failed",
+		},
+	}
+
+	for _, test := range tests {
+		_, err := test.config.ResolveSecrets()
+		if err == nil {
+			t.Fatalf("error expected for %v", test.config)
+		}
+		if err.Error() != test.errMsg {
+			t.Fatalf("unexpected error for %v: %v", test.config, err)
+		}
+	}
+}
+
+func TestDecodeSecretMasksErrors(t *testing.T) {
+	c := Config{"timeout": Secret("hunter2")}
+
+	var timeout time.Duration
+	err := c.Decode("timeout", &timeout)
+	if err == nil {
+		t.Fatalf("error expected")
+	}
+	if strings.Contains(err.Error(), "hunter2") || !strings.Contains(err.Error(), secretMask) {
+		t.Fatalf("secret value in error: %v", err)
+	}
+}
+
+func TestDecodeUnresolvedSecret(t *testing.T) {
+	c := Config{"db": map[string]interface{}{"password": "secret://env/DB_PASS"}}
+
+	var db struct{ Password string }
+	err := c.Decode("db", &db)
+	if err == nil {
+		t.Fatalf("error expected")
+	}
+	if err.Error() != "cannot decode key 'db': [struct field 'Password'] unresolved secret reference 'secret://env/DB_PASS' (see Config.ResolveSecrets)" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	store := NewStore[struct{ Password string }]("db")
+	if err := store.Update(c); err == nil {
+		t.Fatalf("error expected")
+	}
+}
+
+func TestConfigInterpolateSecrets(t *testing.T) {
+	t.Setenv("CONF_TEST_SECRET", "hunter2")
+
+	c := Config{
+		"pw":  "secret://env/CONF_TEST_SECRET",
+		"dsn": "db://${pw}@localhost",
+		"key": "${pw}",
+	}
+	res, err := c.ResolveSecrets()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res, err = res.Interpolate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if res["dsn"] != Secret("db://hunter2@localhost") || res["key"] != Secret("hunter2") {
+		t.Fatalf("unexpected configuration: %#v", map[string]interface{}(res))
+	}
+	if dump := fmt.Sprint(res); strings.Contains(dump, "hunter2") {
+		t.Fatalf("secret value in dump: %s", dump)
+	}
+
+	var dsn string
+	if err := res.Decode("dsn", &dsn); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dsn != "db://hunter2@localhost" {
+		t.Fatalf("unexpected dsn: %s", dsn)
+	}
+}
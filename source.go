@@ -0,0 +1,257 @@
+package conf
+
+import (
+	"context"
+	"path/filepath"
+)
+
+// Source represents a source of configuration values, e.g. a file, the
+// environment or a key-value store.
+type Source interface {
+	// Load loads the current configuration of the source.
+	Load(ctx context.Context) (Config, error)
+}
+
+// WatchableSource represents a source which notifies about changes of
+// its configuration.
+type WatchableSource interface {
+	Source
+
+	// Watch watches the source for changes. The returned channel receives an
+	// event with the new configuration after each change, and an event with
+	// an error if the source could not be loaded. The channel is closed when
+	// ctx is done.
+	Watch(ctx context.Context) <-chan Event
+}
+
+// Event describes a change of a watched source.
+type Event struct {
+	Config Config // new configuration (nil if Err is set)
+	Err    error
+}
+
+// MultiSource combines several sources into a single source. The
+// configurations of the sources are merged in the order of the sources, so
+// later sources take precedence over earlier ones. The zero value is an
+// empty source which merges with the default options.
+type MultiSource struct {
+	// Merger defines how the configurations are merged.
+	Merger Merger
+
+	// Sources holds the combined sources.
+	Sources []Source
+}
+
+// Load loads the configurations of all sources and returns the merged
+// configuration. If one of the sources fails an error is returned.
+func (m *MultiSource) Load(ctx context.Context) (Config, error) {
+	configs := make([]Config, len(m.Sources))
+	for i, s := range m.Sources {
+		c, err := s.Load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		configs[i] = c
+	}
+	return m.Merger.Merge(configs...), nil
+}
+
+// Watch loads all sources and watches those which implement
+// WatchableSource. Whenever a source changes, an event with the newly
+// merged configuration is sent. Errors of the sources are passed through.
+// If the initial loading fails, an error event is sent and the channel is
+// closed.
+func (m *MultiSource) Watch(ctx context.Context) <-chan Event {
+	ch := make(chan Event)
+	go func() {
+		defer close(ch)
+
+		configs := make([]Config, len(m.Sources))
+		for i, s := range m.Sources {
+			c, err := s.Load(ctx)
+			if err != nil {
+				sendEvent(ctx, ch, Event{Err: err})
+				return
+			}
+			configs[i] = c
+		}
+
+		type sourceEvent struct {
+			index int
+			Event
+		}
+		events := make(chan sourceEvent)
+		watching := 0
+		for i, s := range m.Sources {
+			ws, ok := s.(WatchableSource)
+			if !ok {
+				continue
+			}
+			watching++
+			go func(i int, src <-chan Event) {
+				for ev := range src {
+					select {
+					case events <- sourceEvent{index: i, Event: ev}:
+					case <-ctx.Done():
+					}
+				}
+				select {
+				case events <- sourceEvent{index: -1}:
+				case <-ctx.Done():
+				}
+			}(i, ws.Watch(ctx))
+		}
+
+		for watching > 0 {
+			var ev sourceEvent
+			select {
+			case ev = <-events:
+			case <-ctx.Done():
+				return
+			}
+
+			switch {
+			case ev.index < 0:
+				watching--
+				continue
+			case ev.Err != nil:
+				sendEvent(ctx, ch, ev.Event)
+				continue
+			}
+			configs[ev.index] = ev.Config
+			sendEvent(ctx, ch, Event{Config: m.Merger.Merge(configs...)})
+		}
+	}()
+	return ch
+}
+
+// FileSource returns a source which loads the configuration file with the
+// given path. If unmarshal is nil, the parser is chosen by the file's
+// extension (see LoadFile). The source is watched as described for Watch
+// with the given options, except that errors are sent as events.
+func FileSource(path string, unmarshal Unmarshaler, opts ...WatchOption) WatchableSource {
+	return &fileSource{
+		path:      path,
+		unmarshal: unmarshal,
+		opts:      opts,
+	}
+}
+
+type fileSource struct {
+	path      string
+	unmarshal Unmarshaler
+	opts      []WatchOption
+}
+
+func (s *fileSource) Load(ctx context.Context) (Config, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	unmarshal := s.unmarshal
+	if unmarshal == nil {
+		var err error
+		if unmarshal, err = lookupFormat(filepath.Ext(s.path)); err != nil {
+			return nil, err
+		}
+	}
+	p := &filePoller{path: s.path, unmarshal: unmarshal}
+	c, _, err := p.load()
+	return c, err
+}
+
+func (s *fileSource) Watch(ctx context.Context) <-chan Event {
+	return watchEvents(ctx, func(fn func(old, new Config), opts ...WatchOption) (*Watcher, error) {
+		return Watch(s.path, s.unmarshal, fn, append(append([]WatchOption{}, s.opts...), opts...)...)
+	})
+}
+
+// URLSource returns a source which loads the configuration from the given
+// HTTP(S) URL. If unmarshal is nil, the parser is chosen by the response's
+// Content-Type (see WatchURL). The source is watched as described for
+// WatchURL with the given options, except that errors are sent as events.
+func URLSource(url string, unmarshal Unmarshaler, opts ...WatchOption) WatchableSource {
+	return &urlSource{
+		url:       url,
+		unmarshal: unmarshal,
+		opts:      opts,
+	}
+}
+
+type urlSource struct {
+	url       string
+	unmarshal Unmarshaler
+	opts      []WatchOption
+}
+
+func (s *urlSource) Load(ctx context.Context) (Config, error) {
+	p := newHTTPPoller(s.url, s.unmarshal, newWatchOptions(s.opts))
+	return p.fetch(ctx)
+}
+
+func (s *urlSource) Watch(ctx context.Context) <-chan Event {
+	return watchEvents(ctx, func(fn func(old, new Config), opts ...WatchOption) (*Watcher, error) {
+		return WatchURL(s.url, s.unmarshal, fn, append(append([]WatchOption{}, s.opts...), opts...)...)
+	})
+}
+
+// EnvSource returns a source which loads the configuration from the
+// environment variables with the given prefix (see FromEnv).
+func EnvSource(prefix string, opts ...EnvOption) Source {
+	return &envSource{
+		prefix: prefix,
+		opts:   opts,
+	}
+}
+
+type envSource struct {
+	prefix string
+	opts   []EnvOption
+}
+
+func (s *envSource) Load(ctx context.Context) (Config, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return FromEnv(s.prefix, s.opts...), nil
+}
+
+// Load returns the values of all flags which were set (see Flags.Config).
+// It implements the Source interface.
+func (f *Flags) Load(ctx context.Context) (Config, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return f.Config(), nil
+}
+
+// watchEvents starts a Watcher with start and forwards its changes and
+// errors as events until ctx is done.
+func watchEvents(ctx context.Context, start func(fn func(old, new Config), opts ...WatchOption) (*Watcher, error)) <-chan Event {
+	ch := make(chan Event)
+	go func() {
+		defer close(ch)
+
+		w, err := start(func(old, new Config) {
+			sendEvent(ctx, ch, Event{Config: new})
+		}, WatchErrors(func(err error) {
+			sendEvent(ctx, ch, Event{Err: err})
+		}))
+		if err != nil {
+			sendEvent(ctx, ch, Event{Err: err})
+			return
+		}
+
+		<-ctx.Done()
+		w.Close()
+	}()
+	return ch
+}
+
+// sendEvent sends ev to ch unless ctx is done.
+func sendEvent(ctx context.Context, ch chan<- Event, ev Event) {
+	select {
+	case ch <- ev:
+	case <-ctx.Done():
+	}
+}
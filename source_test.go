@@ -0,0 +1,134 @@
+package conf
+
+import (
+	"context"
+	"flag"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMultiSource(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTestFile(t, dir, "config.toml", `
+name = "file"
+[server]
+host = "localhost"
+port = 80
+`)
+
+	t.Setenv("CONF_SRC_SERVER_PORT", "8080")
+
+	srv := httptest.NewServer(&testDocument{
+		contentType: "application/json",
+		body:        `{"server": {"tls": true}}`,
+	})
+	defer srv.Close()
+
+	var opts struct {
+		Name string `config:"name"`
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags, err := RegisterFlags(fs, "", &opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := fs.Parse([]string{"-name", "flag"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	src := &MultiSource{
+		Sources: []Source{
+			FileSource(path, nil),
+			URLSource(srv.URL, nil),
+			EnvSource("CONF_SRC"),
+			flags,
+		},
+	}
+	c, err := src.Load(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if c["name"] != "flag" {
+		t.Fatalf("unexpected name: %v", c["name"])
+	}
+	var server struct {
+		Host string
+		Port int
+		TLS  bool
+	}
+	if err := c.Decode("server", &server); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if server.Host != "localhost" || server.Port != 8080 || !server.TLS {
+		t.Fatalf("unexpected server configuration: %+v", server)
+	}
+
+	src.Sources = append(src.Sources, FileSource(dir+"/missing.toml", nil))
+	if _, err := src.Load(context.Background()); err == nil {
+		t.Fatalf("expected error, got none")
+	}
+}
+
+func TestMultiSourceWatch(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTestFile(t, dir, "config.json", `{"file": 1}`)
+
+	kv := &MemoryKV{}
+	kv.Set("app/kv", "1")
+
+	src := &MultiSource{
+		Sources: []Source{
+			FileSource(path, nil, WatchInterval(5*time.Millisecond), WatchDebounce(5*time.Millisecond)),
+			KVSource(kv, "app/"),
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := src.Watch(ctx)
+
+	// wait until all sources are watched
+	time.Sleep(50 * time.Millisecond)
+
+	kv.Set("app/kv", "2")
+	ev := nextEvent(t, events)
+	if ev.Err != nil {
+		t.Fatalf("unexpected error: %v", ev.Err)
+	}
+	if ev.Config["kv"] != "2" || ev.Config["file"] != 1.0 {
+		t.Fatalf("unexpected configuration: %v", ev.Config)
+	}
+
+	writeTestFile(t, dir, "config.json", `{"file": 2}`)
+	ev = nextEvent(t, events)
+	if ev.Err != nil {
+		t.Fatalf("unexpected error: %v", ev.Err)
+	}
+	if ev.Config["kv"] != "2" || ev.Config["file"] != 2.0 {
+		t.Fatalf("unexpected configuration: %v", ev.Config)
+	}
+
+	writeTestFile(t, dir, "config.json", `{"file": `)
+	if ev = nextEvent(t, events); ev.Err == nil {
+		t.Fatalf("expected error, got %v", ev.Config)
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestMultiSourceWatchError(t *testing.T) {
+	src := &MultiSource{
+		Sources: []Source{FileSource("missing.json", nil)},
+	}
+	events := src.Watch(context.Background())
+	if ev := nextEvent(t, events); ev.Err == nil {
+		t.Fatalf("expected error, got %v", ev.Config)
+	}
+	if _, ok := <-events; ok {
+		t.Fatalf("unexpected event")
+	}
+}
@@ -0,0 +1,71 @@
+package conf
+
+import (
+	"fmt"
+	"reflect"
+	"sync/atomic"
+)
+
+// Validator can be implemented by configuration types which have to be
+// validated after decoding (see Store).
+type Validator interface {
+	Validate() error
+}
+
+// Store holds a decoded configuration of type T which can be replaced
+// atomically. It is safe for concurrent use: readers always see a complete
+// snapshot, even while the configuration is updated.
+type Store[T any] struct {
+	key string
+	ptr atomic.Pointer[T]
+}
+
+// NewStore creates an empty store which decodes the configuration value
+// with the given key into T. If key is empty the whole configuration is
+// decoded.
+func NewStore[T any](key string) *Store[T] {
+	return &Store[T]{key: key}
+}
+
+// Load returns the current snapshot of the configuration or nil if the
+// store was not updated successfully yet. The returned value must not be
+// modified. Load never blocks.
+func (s *Store[T]) Load() *T {
+	return s.ptr.Load()
+}
+
+// Update decodes the configuration c into a new value of type T (see
+// Config.Decode) and replaces the current snapshot with it. If *T or T
+// implements Validator, the new value is validated before. If decoding or
+// validation fails, the current snapshot is kept and an error is returned.
+//
+// Update can be used as part of the callback of a Watcher:
+//   store := conf.NewStore[serverConf]("server")
+//   w, err := conf.Watch(path, nil, func(_, c conf.Config) {
+//       if err := store.Update(c); err != nil {
+//           log.Printf("invalid configuration: %v", err)
+//       }
+//   })
+func (s *Store[T]) Update(c Config) error {
+	t := new(T)
+	if s.key == "" {
+		if err := decode(reflect.ValueOf(t), reflect.ValueOf(c)); err != nil {
+			return fmt.Errorf("cannot decode configuration: %v", err)
+		}
+	} else if err := c.Decode(s.key, t); err != nil {
+		return err
+	}
+
+	var v interface{} = t
+	if _, ok := v.(Validator); !ok {
+		v = *t
+	}
+	if validator, ok := v.(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			return fmt.Errorf("invalid configuration: %v", err)
+		}
+	}
+
+	s.ptr.Store(t)
+	return nil
+}
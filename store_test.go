@@ -0,0 +1,104 @@
+package conf
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+type storeTestConf struct {
+	Address string
+	Port    int
+}
+
+func (c *storeTestConf) Validate() error {
+	if c.Port <= 0 {
+		return errors.New("port must be positive")
+	}
+	return nil
+}
+
+func TestStore(t *testing.T) {
+	s := NewStore[storeTestConf]("server")
+	if s.Load() != nil {
+		t.Fatalf("unexpected snapshot: %+v", s.Load())
+	}
+
+	err := s.Update(Config{
+		"server": map[string]interface{}{"address": "192.168.1.7", "port": 8080},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	first := s.Load()
+	if first == nil || first.Address != "192.168.1.7" || first.Port != 8080 {
+		t.Fatalf("unexpected snapshot: %+v", first)
+	}
+
+	// decoding error
+	err = s.Update(Config{
+		"server": map[string]interface{}{"port": "abc"},
+	})
+	if err == nil {
+		t.Fatalf("expected error, got none")
+	}
+
+	// validation error
+	err = s.Update(Config{
+		"server": map[string]interface{}{"port": -1},
+	})
+	if err == nil {
+		t.Fatalf("expected error, got none")
+	}
+	if s.Load() != first {
+		t.Fatalf("unexpected snapshot: %+v", s.Load())
+	}
+
+	err = s.Update(Config{
+		"server": map[string]interface{}{"port": 9090},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.Load().Port != 9090 || s.Load().Address != "" || first.Port != 8080 {
+		t.Fatalf("unexpected snapshots: %+v, %+v", s.Load(), first)
+	}
+}
+
+func TestStoreRootKey(t *testing.T) {
+	s := NewStore[map[string]int]("")
+	if err := s.Update(Config{"a": 1, "b": "2"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m := *s.Load(); len(m) != 2 || m["a"] != 1 || m["b"] != 2 {
+		t.Fatalf("unexpected snapshot: %v", m)
+	}
+
+	if err := s.Update(Config{"a": "x"}); err == nil {
+		t.Fatalf("expected error, got none")
+	}
+}
+
+func TestStoreConcurrency(t *testing.T) {
+	s := NewStore[storeTestConf]("")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for port := 1; port <= 100; port++ {
+				s.Update(Config{"port": port})
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if c := s.Load(); c != nil && c.Port <= 0 {
+					t.Errorf("unexpected snapshot: %+v", c)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,969 @@
+package conf
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// TOML parses the TOML (v1.0) encoded data and stores the result in value.
+// It can be passed to Load or registered with RegisterFormat.
+//
+// Tables and inline tables are stored as map[string]interface{}, arrays and
+// arrays of tables as []interface{}. Integers are stored as int64, floats as
+// float64 and all kinds of date-times as time.Time (local date-times, dates
+// and times use the local time zone). Syntax errors are reported as
+// *SyntaxError.
+func TOML(data []byte, value interface{}) error {
+	m, err := parseTOML(string(data), nil)
+	if err != nil {
+		return err
+	}
+	return storeMap(m, value)
+}
+
+type tomlTableKind int
+
+const (
+	tomlImplicit tomlTableKind = iota // created implicitly by a header
+	tomlExplicit                      // defined by a header
+	tomlDotted                        // created by a dotted key
+	tomlInline                        // defined by an inline table
+)
+
+type tomlTable struct {
+	kind   tomlTableKind
+	path   []string // keys of the table (not set for inline tables)
+	values map[string]interface{}
+}
+
+type tomlTableArray struct {
+	tables []*tomlTable
+}
+
+type tomlParser struct {
+	data    string
+	pos     int
+	root    *tomlTable
+	current *tomlTable
+	inline  int            // nesting level of inline tables
+	lines   map[string]int // line of each key (optional)
+	lc      lineCounter
+}
+
+// parseTOML parses the TOML encoded data. If lines is not nil, the line
+// of each key is stored in it.
+func parseTOML(data string, lines map[string]int) (map[string]interface{}, error) {
+	p := &tomlParser{data: data, lines: lines, lc: lineCounter{data: data}}
+	p.root = newTOMLTable(tomlExplicit, nil)
+	p.current = p.root
+
+	if !utf8.ValidString(data) {
+		return nil, p.errorf("invalid UTF-8 encoding")
+	}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return p.root.toMap(), nil
+}
+
+func newTOMLTable(kind tomlTableKind, path []string) *tomlTable {
+	return &tomlTable{
+		kind:   kind,
+		path:   path,
+		values: make(map[string]interface{}),
+	}
+}
+
+func (p *tomlParser) parse() error {
+	for {
+		p.skipWhitespace()
+		if p.eof() {
+			return nil
+		}
+
+		switch p.peek() {
+		case '#', '\r', '\n':
+			// empty line
+		case '[':
+			if err := p.parseHeader(); err != nil {
+				return err
+			}
+		default:
+			if err := p.parseKeyValue(p.current); err != nil {
+				return err
+			}
+		}
+
+		if err := p.parseLineEnd(); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *tomlParser) parseLineEnd() error {
+	p.skipWhitespace()
+	if p.eof() {
+		return nil
+	}
+	if p.peek() == '#' {
+		if err := p.skipComment(); err != nil {
+			return err
+		}
+		if p.eof() {
+			return nil
+		}
+	}
+	if !p.consumeNewline() {
+		return p.errorf("expected end of line, found %s", p.describe())
+	}
+	return nil
+}
+
+func (p *tomlParser) parseHeader() error {
+	p.pos++ // '['
+	array := p.consume('[')
+
+	p.skipWhitespace()
+	keyPos := p.pos
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipWhitespace()
+
+	if !p.consume(']') || (array && !p.consume(']')) {
+		if array {
+			return p.errorf("expected ']]', found %s", p.describe())
+		}
+		return p.errorf("expected ']', found %s", p.describe())
+	}
+
+	t := p.root
+	for i, key := range keys[:len(keys)-1] {
+		switch v := t.values[key].(type) {
+		case nil:
+			next := newTOMLTable(tomlImplicit, appendKeys(t.path, key))
+			t.values[key] = next
+			t = next
+		case *tomlTable:
+			if v.kind == tomlInline {
+				return p.errorAt(keyPos, "cannot extend inline table '%s'", joinKeys(keys[:i+1]))
+			}
+			t = v
+		case *tomlTableArray:
+			t = v.tables[len(v.tables)-1]
+		default:
+			return p.errorAt(keyPos, "key '%s' is not a table", joinKeys(keys[:i+1]))
+		}
+	}
+
+	key := keys[len(keys)-1]
+	existing := t.values[key]
+	if array {
+		switch v := existing.(type) {
+		case nil:
+			p.current = newTOMLTable(tomlExplicit, appendKeys(t.path, key, "0"))
+			t.values[key] = &tomlTableArray{tables: []*tomlTable{p.current}}
+		case *tomlTableArray:
+			p.current = newTOMLTable(tomlExplicit, appendKeys(t.path, key, strconv.Itoa(len(v.tables))))
+			v.tables = append(v.tables, p.current)
+		default:
+			return p.errorAt(keyPos, "key '%s' is not an array of tables", joinKeys(keys))
+		}
+		p.record(p.current.path, keyPos)
+		return nil
+	}
+
+	switch v := existing.(type) {
+	case nil:
+		p.current = newTOMLTable(tomlExplicit, appendKeys(t.path, key))
+		t.values[key] = p.current
+	case *tomlTable:
+		if v.kind != tomlImplicit {
+			return p.errorAt(keyPos, "table '%s' already defined", joinKeys(keys))
+		}
+		v.kind = tomlExplicit
+		p.current = v
+	default:
+		return p.errorAt(keyPos, "key '%s' already defined", joinKeys(keys))
+	}
+	p.record(p.current.path, keyPos)
+	return nil
+}
+
+func (p *tomlParser) parseKeyValue(t *tomlTable) error {
+	keyPos := p.pos
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+
+	p.skipWhitespace()
+	if !p.consume('=') {
+		return p.errorf("expected '=', found %s", p.describe())
+	}
+	p.skipWhitespace()
+
+	val, err := p.parseValue()
+	if err != nil {
+		return err
+	}
+
+	for i, key := range keys[:len(keys)-1] {
+		switch v := t.values[key].(type) {
+		case nil:
+			next := newTOMLTable(tomlDotted, appendKeys(t.path, key))
+			t.values[key] = next
+			t = next
+		case *tomlTable:
+			if v.kind != tomlDotted {
+				return p.errorAt(keyPos, "cannot extend table '%s' with dotted keys", joinKeys(keys[:i+1]))
+			}
+			t = v
+		default:
+			return p.errorAt(keyPos, "key '%s' is not a table", joinKeys(keys[:i+1]))
+		}
+	}
+
+	key := keys[len(keys)-1]
+	if _, has := t.values[key]; has {
+		return p.errorAt(keyPos, "key '%s' already defined", joinKeys(keys))
+	}
+	t.values[key] = val
+	if p.inline == 0 {
+		p.record(appendKeys(t.path, key), keyPos)
+	}
+	return nil
+}
+
+func (p *tomlParser) parseKey() ([]string, error) {
+	var keys []string
+	for {
+		key, err := p.parseSimpleKey()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+
+		p.skipWhitespace()
+		if !p.consume('.') {
+			return keys, nil
+		}
+		p.skipWhitespace()
+	}
+}
+
+func (p *tomlParser) parseSimpleKey() (string, error) {
+	if p.eof() {
+		return "", p.errorf("expected key, found end of file")
+	}
+
+	switch p.peek() {
+	case '"':
+		if p.hasPrefix(`"""`) {
+			return "", p.errorf("multi-line strings are not allowed as keys")
+		}
+		return p.parseBasicString()
+	case '\'':
+		if p.hasPrefix(`'''`) {
+			return "", p.errorf("multi-line strings are not allowed as keys")
+		}
+		return p.parseLiteralString()
+	}
+
+	start := p.pos
+	for !p.eof() && isBareKeyChar(p.peek()) {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", p.errorf("expected key, found %s", p.describe())
+	}
+	return p.data[start:p.pos], nil
+}
+
+func (p *tomlParser) parseValue() (interface{}, error) {
+	if p.eof() {
+		return nil, p.errorf("expected value, found end of file")
+	}
+
+	switch c := p.peek(); {
+	case c == '"':
+		if p.hasPrefix(`"""`) {
+			return p.parseMultilineBasicString()
+		}
+		return p.parseBasicString()
+	case c == '\'':
+		if p.hasPrefix(`'''`) {
+			return p.parseMultilineLiteralString()
+		}
+		return p.parseLiteralString()
+	case c == '[':
+		return p.parseArray()
+	case c == '{':
+		return p.parseInlineTable()
+	case p.hasPrefix("true"):
+		p.pos += 4
+		return true, p.checkValueEnd()
+	case p.hasPrefix("false"):
+		p.pos += 5
+		return false, p.checkValueEnd()
+	case c == '+' || c == '-' || c == 'i' || c == 'n' || isDigit(c):
+		return p.parseNumberOrDate()
+	default:
+		return nil, p.errorf("expected value, found %s", p.describe())
+	}
+}
+
+// checkValueEnd ensures that a keyword value is not followed by
+// characters which would be part of a bare word.
+func (p *tomlParser) checkValueEnd() error {
+	if !p.eof() && isBareKeyChar(p.peek()) {
+		return p.errorf("unexpected character %s", p.describe())
+	}
+	return nil
+}
+
+func (p *tomlParser) parseArray() (interface{}, error) {
+	p.pos++ // '['
+
+	arr := []interface{}{}
+	for {
+		if err := p.skipArrayWhitespace(); err != nil {
+			return nil, err
+		}
+		if p.consume(']') {
+			return arr, nil
+		}
+
+		val, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, val)
+
+		if err := p.skipArrayWhitespace(); err != nil {
+			return nil, err
+		}
+		if p.consume(']') {
+			return arr, nil
+		}
+		if !p.consume(',') {
+			return nil, p.errorf("expected ',' or ']', found %s", p.describe())
+		}
+	}
+}
+
+func (p *tomlParser) skipArrayWhitespace() error {
+	for {
+		p.skipWhitespace()
+		switch {
+		case p.eof():
+			return p.errorf("unterminated array")
+		case p.peek() == '#':
+			if err := p.skipComment(); err != nil {
+				return err
+			}
+		case p.consumeNewline():
+		default:
+			return nil
+		}
+	}
+}
+
+func (p *tomlParser) parseInlineTable() (interface{}, error) {
+	p.pos++ // '{'
+	p.inline++
+	defer func() { p.inline-- }()
+
+	t := newTOMLTable(tomlInline, nil)
+	p.skipWhitespace()
+	if p.consume('}') {
+		return t, nil
+	}
+
+	for {
+		p.skipWhitespace()
+		if err := p.parseKeyValue(t); err != nil {
+			return nil, err
+		}
+
+		p.skipWhitespace()
+		if p.consume('}') {
+			return t, nil
+		}
+		if !p.consume(',') {
+			return nil, p.errorf("expected ',' or '}', found %s", p.describe())
+		}
+	}
+}
+
+func (p *tomlParser) parseBasicString() (string, error) {
+	p.pos++ // '"'
+
+	var sb strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated string")
+		}
+
+		c := p.peek()
+		switch {
+		case c == '"':
+			p.pos++
+			return sb.String(), nil
+		case c == '\\':
+			if err := p.parseEscape(&sb); err != nil {
+				return "", err
+			}
+		case c == '\n' || c == '\r':
+			return "", p.errorf("unterminated string")
+		case isControl(c):
+			return "", p.errorf("control character %s in string", p.describe())
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+func (p *tomlParser) parseMultilineBasicString() (string, error) {
+	p.pos += 3 // '"""'
+	p.consumeNewline()
+
+	var sb strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated string")
+		}
+
+		c := p.peek()
+		switch {
+		case c == '"':
+			if n, ok, err := p.parseMultilineEnd('"'); err != nil {
+				return "", err
+			} else if ok {
+				sb.WriteString(strings.Repeat(`"`, n))
+				return sb.String(), nil
+			}
+			sb.WriteByte(c)
+			p.pos++
+		case c == '\\':
+			// line ending backslash
+			i := p.pos + 1
+			for i < len(p.data) && (p.data[i] == ' ' || p.data[i] == '\t') {
+				i++
+			}
+			if i < len(p.data) && (p.data[i] == '\n' || strings.HasPrefix(p.data[i:], "\r\n")) {
+				p.pos = i
+				for !p.eof() {
+					if c := p.peek(); c == ' ' || c == '\t' {
+						p.pos++
+					} else if !p.consumeNewline() {
+						break
+					}
+				}
+				continue
+			}
+			if err := p.parseEscape(&sb); err != nil {
+				return "", err
+			}
+		case p.consumeNewline():
+			sb.WriteByte('\n')
+		case isControl(c):
+			return "", p.errorf("control character %s in string", p.describe())
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+func (p *tomlParser) parseLiteralString() (string, error) {
+	p.pos++ // '\''
+
+	start := p.pos
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated string")
+		}
+
+		c := p.peek()
+		switch {
+		case c == '\'':
+			p.pos++
+			return p.data[start : p.pos-1], nil
+		case c == '\n' || c == '\r':
+			return "", p.errorf("unterminated string")
+		case isControl(c):
+			return "", p.errorf("control character %s in string", p.describe())
+		default:
+			p.pos++
+		}
+	}
+}
+
+func (p *tomlParser) parseMultilineLiteralString() (string, error) {
+	p.pos += 3 // "'''"
+	p.consumeNewline()
+
+	var sb strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated string")
+		}
+
+		c := p.peek()
+		switch {
+		case c == '\'':
+			if n, ok, err := p.parseMultilineEnd('\''); err != nil {
+				return "", err
+			} else if ok {
+				sb.WriteString(strings.Repeat("'", n))
+				return sb.String(), nil
+			}
+			sb.WriteByte(c)
+			p.pos++
+		case p.consumeNewline():
+			sb.WriteByte('\n')
+		case isControl(c):
+			return "", p.errorf("control character %s in string", p.describe())
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+// parseMultilineEnd checks whether the current position holds the closing
+// delimiter of a multi-line string. Up to two additional quotes are allowed
+// in front of the delimiter; their number is returned.
+func (p *tomlParser) parseMultilineEnd(quote byte) (int, bool, error) {
+	n := 0
+	for p.pos+n < len(p.data) && p.data[p.pos+n] == quote {
+		n++
+	}
+	switch {
+	case n < 3:
+		return 0, false, nil
+	case n > 5:
+		return 0, false, p.errorAt(p.pos+5, "too many quotes")
+	}
+	p.pos += n
+	return n - 3, true, nil
+}
+
+func (p *tomlParser) parseEscape(sb *strings.Builder) error {
+	start := p.pos
+	p.pos++ // '\\'
+	if p.eof() {
+		return p.errorf("unterminated string")
+	}
+
+	c := p.peek()
+	p.pos++
+	switch c {
+	case 'b':
+		sb.WriteByte('\b')
+	case 't':
+		sb.WriteByte('\t')
+	case 'n':
+		sb.WriteByte('\n')
+	case 'f':
+		sb.WriteByte('\f')
+	case 'r':
+		sb.WriteByte('\r')
+	case '"':
+		sb.WriteByte('"')
+	case '\\':
+		sb.WriteByte('\\')
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		if p.pos+n > len(p.data) {
+			return p.errorAt(start, "invalid unicode escape")
+		}
+		code, err := strconv.ParseUint(p.data[p.pos:p.pos+n], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return p.errorAt(start, "invalid unicode escape '%s'", p.data[start:p.pos+n])
+		}
+		sb.WriteRune(rune(code))
+		p.pos += n
+	default:
+		return p.errorAt(start, "invalid escape sequence '\\%c'", c)
+	}
+	return nil
+}
+
+func (p *tomlParser) parseNumberOrDate() (interface{}, error) {
+	start := p.pos
+	for !p.eof() && isNumberChar(p.peek()) {
+		p.pos++
+	}
+	tok := p.data[start:p.pos]
+
+	// a date and a time may be separated by a space
+	if len(tok) == 10 && isTOMLDate(tok) && p.pos+1 < len(p.data) && p.data[p.pos] == ' ' && isDigit(p.data[p.pos+1]) {
+		p.pos++
+		for !p.eof() && isNumberChar(p.peek()) {
+			p.pos++
+		}
+		tok = p.data[start:p.pos]
+	}
+
+	if len(tok) >= 10 && isTOMLDate(tok[:10]) || len(tok) >= 3 && tok[2] == ':' {
+		t, ok := parseTOMLDateTime(tok)
+		if !ok {
+			return nil, p.errorAt(start, "invalid date-time '%s'", tok)
+		}
+		return t, nil
+	}
+
+	if v, ok := parseTOMLNumber(tok); ok {
+		return v, nil
+	}
+	return nil, p.errorAt(start, "invalid number '%s'", tok)
+}
+
+func parseTOMLNumber(s string) (interface{}, bool) {
+	sign := ""
+	unsigned := s
+	if s != "" && (s[0] == '+' || s[0] == '-') {
+		sign, unsigned = s[:1], s[1:]
+	}
+
+	switch unsigned {
+	case "inf":
+		if sign == "-" {
+			return math.Inf(-1), true
+		}
+		return math.Inf(1), true
+	case "nan":
+		return math.NaN(), true
+	}
+
+	if len(unsigned) > 2 && unsigned[0] == '0' {
+		base := 0
+		isValid := isDigit
+		switch unsigned[1] {
+		case 'x':
+			base, isValid = 16, isHexDigit
+		case 'o':
+			base, isValid = 8, isOctDigit
+		case 'b':
+			base, isValid = 2, isBinDigit
+		}
+		if base != 0 {
+			digits := unsigned[2:]
+			if sign != "" || !validUnderscores(digits, isValid) {
+				return nil, false
+			}
+			i, err := strconv.ParseInt(strings.Replace(digits, "_", "", -1), base, 64)
+			if err != nil {
+				return nil, false
+			}
+			return i, true
+		}
+	}
+
+	intPart := unsigned
+	if i := strings.IndexAny(unsigned, ".eE"); i >= 0 {
+		intPart = unsigned[:i]
+	}
+	if !validUnderscores(intPart, isDigit) || len(intPart) > 1 && intPart[0] == '0' {
+		return nil, false
+	}
+
+	if len(intPart) == len(unsigned) {
+		i, err := strconv.ParseInt(sign+strings.Replace(unsigned, "_", "", -1), 10, 64)
+		if err != nil {
+			return nil, false
+		}
+		return i, true
+	}
+
+	rest := unsigned[len(intPart):]
+	if rest[0] == '.' {
+		frac := rest[1:]
+		if i := strings.IndexAny(frac, "eE"); i >= 0 {
+			frac = frac[:i]
+		}
+		if !validUnderscores(frac, isDigit) {
+			return nil, false
+		}
+		rest = rest[1+len(frac):]
+	}
+	if rest != "" {
+		exp := rest[1:] // skip 'e' or 'E'
+		if exp != "" && (exp[0] == '+' || exp[0] == '-') {
+			exp = exp[1:]
+		}
+		if !validUnderscores(exp, isDigit) {
+			return nil, false
+		}
+	}
+
+	f, err := strconv.ParseFloat(sign+strings.Replace(unsigned, "_", "", -1), 64)
+	if err != nil {
+		return nil, false
+	}
+	return f, true
+}
+
+// validUnderscores reports whether s is a non-empty sequence of digits
+// where each underscore is surrounded by digits.
+func validUnderscores(s string, isValid func(byte) bool) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] == '_' {
+			if i == 0 || i == len(s)-1 || !isValid(s[i-1]) || !isValid(s[i+1]) {
+				return false
+			}
+		} else if !isValid(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func parseTOMLDateTime(s string) (time.Time, bool) {
+	switch {
+	case s[2] == ':':
+		// local time
+		if n := tomlTimeLen(s); n != len(s) {
+			return time.Time{}, false
+		}
+		t, err := time.ParseInLocation("15:04:05", s, time.Local)
+		return t, err == nil
+
+	case len(s) == 10:
+		// local date
+		if !isTOMLDate(s) {
+			return time.Time{}, false
+		}
+		t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+		return t, err == nil
+	}
+
+	if !isTOMLDate(s[:10]) || len(s) < 11 {
+		return time.Time{}, false
+	}
+	switch s[10] {
+	case 'T', 't', ' ':
+	default:
+		return time.Time{}, false
+	}
+
+	timePart := s[11:]
+	n := tomlTimeLen(timePart)
+	if n < 0 {
+		return time.Time{}, false
+	}
+	norm := s[:10] + "T" + timePart[:n]
+
+	offset := timePart[n:]
+	switch {
+	case offset == "":
+		// local date-time
+		t, err := time.ParseInLocation("2006-01-02T15:04:05", norm, time.Local)
+		return t, err == nil
+	case offset == "Z" || offset == "z":
+		norm += "Z"
+	case len(offset) == 6 && (offset[0] == '+' || offset[0] == '-') &&
+		isDigit(offset[1]) && isDigit(offset[2]) && offset[3] == ':' && isDigit(offset[4]) && isDigit(offset[5]):
+		norm += offset
+	default:
+		return time.Time{}, false
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, norm)
+	return t, err == nil
+}
+
+func isTOMLDate(s string) bool {
+	return len(s) == 10 &&
+		isDigit(s[0]) && isDigit(s[1]) && isDigit(s[2]) && isDigit(s[3]) && s[4] == '-' &&
+		isDigit(s[5]) && isDigit(s[6]) && s[7] == '-' &&
+		isDigit(s[8]) && isDigit(s[9])
+}
+
+// tomlTimeLen returns the length of the time (hh:mm:ss with an optional
+// fraction) at the beginning of s or -1 if s does not start with a time.
+func tomlTimeLen(s string) int {
+	if len(s) < 8 ||
+		!isDigit(s[0]) || !isDigit(s[1]) || s[2] != ':' ||
+		!isDigit(s[3]) || !isDigit(s[4]) || s[5] != ':' ||
+		!isDigit(s[6]) || !isDigit(s[7]) {
+		return -1
+	}
+	if len(s) == 8 || s[8] != '.' {
+		return 8
+	}
+
+	n := 9
+	for n < len(s) && isDigit(s[n]) {
+		n++
+	}
+	if n == 9 {
+		return -1
+	}
+	return n
+}
+
+func (t *tomlTable) toMap() map[string]interface{} {
+	m := make(map[string]interface{}, len(t.values))
+	for k, v := range t.values {
+		m[k] = tomlToValue(v)
+	}
+	return m
+}
+
+func tomlToValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case *tomlTable:
+		return v.toMap()
+	case *tomlTableArray:
+		arr := make([]interface{}, len(v.tables))
+		for i, t := range v.tables {
+			arr[i] = t.toMap()
+		}
+		return arr
+	case []interface{}:
+		for i := range v {
+			v[i] = tomlToValue(v[i])
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func (p *tomlParser) skipWhitespace() {
+	for !p.eof() {
+		if c := p.peek(); c != ' ' && c != '\t' {
+			return
+		}
+		p.pos++
+	}
+}
+
+func (p *tomlParser) skipComment() error {
+	p.pos++ // '#'
+	for !p.eof() {
+		c := p.peek()
+		if c == '\n' || strings.HasPrefix(p.data[p.pos:], "\r\n") {
+			return nil
+		}
+		if isControl(c) {
+			return p.errorf("control character %s in comment", p.describe())
+		}
+		p.pos++
+	}
+	return nil
+}
+
+func (p *tomlParser) consumeNewline() bool {
+	switch {
+	case p.consume('\n'):
+		return true
+	case p.hasPrefix("\r\n"):
+		p.pos += 2
+		return true
+	}
+	return false
+}
+
+func (p *tomlParser) consume(c byte) bool {
+	if !p.eof() && p.peek() == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *tomlParser) hasPrefix(s string) bool {
+	return strings.HasPrefix(p.data[p.pos:], s)
+}
+
+func (p *tomlParser) peek() byte {
+	return p.data[p.pos]
+}
+
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.data)
+}
+
+// describe returns a printable description of the current character.
+func (p *tomlParser) describe() string {
+	if p.eof() {
+		return "end of file"
+	}
+	r, _ := utf8.DecodeRuneInString(p.data[p.pos:])
+	switch {
+	case r == '\n' || r == '\r':
+		return "end of line"
+	case r < 0x20 || r == 0x7f:
+		return strconv.QuoteRune(r)
+	}
+	return "'" + string(r) + "'"
+}
+
+func (p *tomlParser) errorf(msg string, args ...interface{}) error {
+	return p.errorAt(p.pos, msg, args...)
+}
+
+func (p *tomlParser) errorAt(pos int, msg string, args ...interface{}) error {
+	if pos > len(p.data) {
+		pos = len(p.data)
+	}
+	return syntaxError("toml", p.data, pos, msg, args...)
+}
+
+// record stores the line of the key with the given path.
+func (p *tomlParser) record(path []string, pos int) {
+	if p.lines != nil {
+		p.lines[joinKeys(path)] = p.lc.lineAt(pos)
+	}
+}
+
+func appendKeys(path []string, keys ...string) []string {
+	return append(path[:len(path):len(path)], keys...)
+}
+
+func joinKeys(keys []string) string {
+	return strings.Join(keys, ".")
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || isDigit(c) || c == '_' || c == '-'
+}
+
+func isNumberChar(c byte) bool {
+	return isBareKeyChar(c) || c == '+' || c == '.' || c == ':'
+}
+
+func isControl(c byte) bool {
+	return c < 0x20 && c != '\t' || c == 0x7f
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+func isOctDigit(c byte) bool {
+	return c >= '0' && c <= '7'
+}
+
+func isBinDigit(c byte) bool {
+	return c == '0' || c == '1'
+}
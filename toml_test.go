@@ -0,0 +1,274 @@
+package conf
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTOMLValues(t *testing.T) {
+	c, err := Load(bytes.NewBufferString(`
+# comment
+str = "I'm a string. \"You can quote me\". Name\tJos\u00E9\nLocation\tSF." # comment
+literal = 'C:\Users\nodejs\templates'
+multi = """
+Roses are red
+Violets are blue"""
+folded = """\
+       The quick brown \
+       fox jumps over \
+       the lazy dog.\
+       """
+quotes = """Here are two quotation marks: "". Simple enough.""""
+multiLiteral = '''
+The first newline is
+trimmed in raw strings.
+'''
+"quoted key" = 1
+'literal key' = 2
+int1 = +99
+int2 = -17
+int3 = 1_000
+hex = 0xDEAD_BEEF
+oct = 0o755
+bin = 0b1101
+flt1 = +1.0
+flt2 = -0.01
+flt3 = 5e+22
+flt4 = 6.626e-34
+flt5 = 224_617.445_991
+inf = -inf
+nan = nan
+bool1 = true
+bool2 = false
+odt1 = 1979-05-27T07:32:00Z
+odt2 = 1979-05-27T00:32:00.999999-07:00
+odt3 = 1979-05-27 07:32:00Z
+ldt = 1979-05-27T07:32:00
+ld = 1979-05-27
+lt = 07:32:00.5
+arr = [ 1, "two", [3.0, 4], { five = 5 }, ] # mixed types
+multiArr = [
+	1, # first
+	2,
+]
+`), TOML)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"str":          "I'm a string. \"You can quote me\". Name\tJos\u00E9\nLocation\tSF.",
+		"literal":      `C:\Users\nodejs\templates`,
+		"multi":        "Roses are red\nViolets are blue",
+		"folded":       "The quick brown fox jumps over the lazy dog.",
+		"quotes":       `Here are two quotation marks: "". Simple enough."`,
+		"multiLiteral": "The first newline is\ntrimmed in raw strings.\n",
+		"quoted key":   int64(1),
+		"literal key":  int64(2),
+		"int1":         int64(99),
+		"int2":         int64(-17),
+		"int3":         int64(1000),
+		"hex":          int64(0xDEADBEEF),
+		"oct":          int64(0755),
+		"bin":          int64(13),
+		"flt1":         1.0,
+		"flt2":         -0.01,
+		"flt3":         5e+22,
+		"flt4":         6.626e-34,
+		"flt5":         224617.445991,
+		"inf":          math.Inf(-1),
+		"bool1":        true,
+		"bool2":        false,
+		"odt1":         time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC),
+		"odt2":         time.Date(1979, 5, 27, 0, 32, 0, 999999000, time.FixedZone("", -7*3600)),
+		"odt3":         time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC),
+		"ldt":          time.Date(1979, 5, 27, 7, 32, 0, 0, time.Local),
+		"ld":           time.Date(1979, 5, 27, 0, 0, 0, 0, time.Local),
+		"lt":           time.Date(0, 1, 1, 7, 32, 0, 500000000, time.Local),
+		"arr": []interface{}{
+			int64(1),
+			"two",
+			[]interface{}{3.0, int64(4)},
+			map[string]interface{}{"five": int64(5)},
+		},
+		"multiArr": []interface{}{int64(1), int64(2)},
+	}
+
+	for key, val := range expected {
+		v, has := c[key]
+		if !has {
+			t.Fatalf("key '%s' not found", key)
+		}
+		if tv, ok := val.(time.Time); ok {
+			if !tv.Equal(v.(time.Time)) {
+				t.Fatalf("unexpected value for '%s': %v", key, v)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(v, val) {
+			t.Fatalf("unexpected value for '%s': %#v", key, v)
+		}
+	}
+	if f, ok := c["nan"].(float64); !ok || !math.IsNaN(f) {
+		t.Fatalf("unexpected value for 'nan': %v", c["nan"])
+	}
+}
+
+func TestTOMLTables(t *testing.T) {
+	c, err := Load(bytes.NewBufferString(`
+title = "example"
+
+[server]
+address = "192.168.1.7"
+port = 8080
+tls.cert = "cert.pem"
+tls.key = "key.pem"
+
+[server.timeouts]
+read = "5s"
+
+[ database . "main.db" ]
+user = { name = "admin", pass = "secret" }
+
+[[backends]]
+name = "a"
+
+[[backends]]
+name = "b"
+[backends.health]
+path = "/health"
+
+[x.y.z]
+[x]
+w = 1
+`), TOML)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var server struct {
+		Address string
+		Port    int
+		TLS     struct {
+			Cert string
+			Key  string
+		}
+		Timeouts struct {
+			Read time.Duration
+		}
+	}
+	if err := c.Decode("server", &server); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if server.Address != "192.168.1.7" ||
+		server.Port != 8080 ||
+		server.TLS.Cert != "cert.pem" ||
+		server.TLS.Key != "key.pem" ||
+		server.Timeouts.Read != 5*time.Second {
+
+		t.Fatalf("unexpected server configuration: %+v", server)
+	}
+
+	db := c["database"].(map[string]interface{})["main.db"]
+	if !reflect.DeepEqual(db, map[string]interface{}{
+		"user": map[string]interface{}{"name": "admin", "pass": "secret"},
+	}) {
+		t.Fatalf("unexpected database configuration: %v", db)
+	}
+
+	var backends []struct {
+		Name   string
+		Health struct {
+			Path string
+		}
+	}
+	if err := c.Decode("backends", &backends); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(backends) != 2 ||
+		backends[0].Name != "a" ||
+		backends[1].Name != "b" ||
+		backends[1].Health.Path != "/health" {
+
+		t.Fatalf("unexpected backends: %+v", backends)
+	}
+
+	var w int
+	if err := c.Decode("x.w", &w); err != nil || w != 1 {
+		t.Fatalf("unexpected value: %v (%v)", w, err)
+	}
+	if _, err := c.Value("x.y.z"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestTOMLErrors(t *testing.T) {
+	invalid := []struct {
+		input  string
+		line   int
+		column int
+	}{
+		{"foo", 1, 4},
+		{"foo = ", 1, 7},
+		{"foo = bar", 1, 7},
+		{"a = 1\na = 2", 2, 1},
+		{"a = 1 b = 2", 1, 7},
+		{"[a]\n[a]", 2, 2},
+		{"[a]\nb = 1\n[a.b]", 3, 2},
+		{"a = [1, 2]\n[[a]]", 2, 3},
+		{"a = { b = 1 }\n[a.c]", 2, 2},
+		{"a = { b = 1, }", 1, 14},
+		{"[a.b.c]\n[a]\nb.d = 1", 3, 1},
+		{"[fruit]\napple.color = 'red'\n[fruit.apple]", 3, 2},
+		{"s = \"abc", 1, 9},
+		{"s = \"a\\qb\"", 1, 7},
+		{"s = \"\\uD800\"", 1, 6},
+		{"s = 'a\nb'", 1, 7},
+		{"i = 012", 1, 5},
+		{"i = 1__2", 1, 5},
+		{"i = _1", 1, 5},
+		{"i = 99999999999999999999", 1, 5},
+		{"f = 1.", 1, 5},
+		{"f = .5", 1, 5},
+		{"x = 0x", 1, 5},
+		{"x = -0x1", 1, 5},
+		{"d = 1979-13-27", 1, 5},
+		{"d = 1979-05-27T25:00:00", 1, 5},
+		{"t = 7:32:00", 1, 5},
+		{"b = truee", 1, 9},
+		{"arr = [1 2]", 1, 10},
+		{"arr = [1,", 1, 10},
+		{"[a\nb]", 1, 3},
+	}
+
+	for _, test := range invalid {
+		_, err := Load(bytes.NewBufferString(test.input), TOML)
+		serr, ok := err.(*SyntaxError)
+		if !ok {
+			t.Fatalf("expected syntax error for %q, got %v", test.input, err)
+		}
+		if serr.Format != "toml" || serr.Line != test.line || serr.Column != test.column {
+			t.Fatalf("unexpected error for %q: %v", test.input, err)
+		}
+	}
+}
+
+func TestTOMLLoadFile(t *testing.T) {
+	path := writeTestFile(t, t.TempDir(), "config.toml", "[foo]\nbar = 7\n")
+
+	c, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var i int
+	if err = c.Decode("foo.bar", &i); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if i != 7 {
+		t.Fatalf("unexpected value: %v", i)
+	}
+}
@@ -49,6 +49,12 @@ func (v *Value) String() (string, error) {
 	return s, err
 }
 
+// Decode stores the configuration value in value, which has to be a
+// pointer. See Config.Decode for details.
+func (v *Value) Decode(value interface{}) error {
+	return v.decode(value)
+}
+
 func (v *Value) decode(value interface{}) error {
 	input := *(*reflect.Value)(v)
 	output := reflect.ValueOf(value)
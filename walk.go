@@ -0,0 +1,60 @@
+package conf
+
+import (
+	"reflect"
+)
+
+// Keys returns the keys of all leaf values of the configuration, i.e. all
+// values which are neither maps nor slices, as well as empty maps and
+// slices. The keys are built as described for Path.String, with the
+// indexes of slice elements as separate segments (e.g. "servers.0.port").
+// They are sorted segment by segment: map keys alphabetically and slice
+// elements by their index.
+func (c Config) Keys() []string {
+	var keys []string
+	walkValue(reflect.ValueOf(c), nil, func(path Path, val reflect.Value) error {
+		if isLeaf(val) {
+			keys = append(keys, path.String())
+		}
+		return nil
+	})
+	return keys
+}
+
+// Walk traverses the configuration depth-first and calls fn for each value,
+// including maps, slices and their elements. A map or slice is visited
+// before its elements, map values in the alphabetical order of their keys
+// and slice elements in the order of their indexes. The path is the key of
+// the value as described for Keys. If fn returns an error, the traversal is
+// stopped and the error is returned.
+func (c Config) Walk(fn func(path string, v *Value) error) error {
+	return walkValue(reflect.ValueOf(c), nil, func(path Path, val reflect.Value) error {
+		v := Value(val)
+		return fn(path.String(), &v)
+	})
+}
+
+// walkValue calls fn for all map values and slice elements nested in val.
+func walkValue(val reflect.Value, path Path, fn func(path Path, val reflect.Value) error) error {
+	var err error
+	eachChild(val, func(name string, elem reflect.Value) {
+		if err != nil {
+			return
+		}
+		p := appendKeys(path, name)
+		if err = fn(p, elem); err == nil {
+			err = walkValue(elem, p, fn)
+		}
+	})
+	return err
+}
+
+// isLeaf reports whether val is neither a map nor a slice, or an empty
+// one.
+func isLeaf(val reflect.Value) bool {
+	switch val = unwrap(val); val.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return val.Len() == 0
+	}
+	return true
+}
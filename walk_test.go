@@ -0,0 +1,98 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestConfigKeys(t *testing.T) {
+	c := Config{
+		"name": "app",
+		"server": map[string]interface{}{
+			"port": 8080,
+			"host": "localhost",
+		},
+		"servers": []interface{}{
+			map[string]interface{}{"port": 80},
+			"b",
+		},
+		"example.com": map[interface{}]interface{}{1: "one"},
+		"empty":       map[string]interface{}{},
+		"none":        []interface{}{},
+		"nil":         nil,
+	}
+
+	expected := []string{
+		"empty",
+		`"example.com".1`,
+		"name",
+		"nil",
+		"none",
+		"server.host",
+		"server.port",
+		"servers.0.port",
+		"servers.1",
+	}
+	if keys := c.Keys(); !reflect.DeepEqual(keys, expected) {
+		t.Fatalf("unexpected keys: %q", keys)
+	}
+
+	for _, key := range expected {
+		if _, err := c.Value(key); err != nil {
+			t.Fatalf("unexpected error for key %s: %v", key, err)
+		}
+	}
+
+	if keys := (Config{}).Keys(); len(keys) != 0 {
+		t.Fatalf("unexpected keys: %q", keys)
+	}
+}
+
+func TestConfigWalk(t *testing.T) {
+	c := Config{
+		"b": []interface{}{1, map[string]interface{}{"c": true}},
+		"a": "x",
+	}
+
+	var visited []string
+	err := c.Walk(func(path string, v *Value) error {
+		s, err := v.String()
+		if err != nil {
+			return err
+		}
+		visited = append(visited, fmt.Sprintf("%s=%s", path, s))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"a=x",
+		"b=[1 map[c:true]]",
+		"b.0=1",
+		"b.1=map[c:true]",
+		"b.1.c=true",
+	}
+	if !reflect.DeepEqual(visited, expected) {
+		t.Fatalf("unexpected visits: %q", visited)
+	}
+
+	errStop := errors.New("stop")
+	n := 0
+	err = c.Walk(func(path string, v *Value) error {
+		n++
+		if path == "b" {
+			return errStop
+		}
+		return nil
+	})
+	if err != errStop {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("unexpected number of visits: %d", n)
+	}
+}
@@ -0,0 +1,299 @@
+package conf
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// WatchOption represents an option which controls the behaviour of a
+// Watcher.
+type WatchOption func(*watchOptions)
+
+type watchOptions struct {
+	interval time.Duration
+	debounce time.Duration
+	backoff  time.Duration
+	client   *http.Client
+	onError  func(error)
+}
+
+// WatchInterval sets the interval in which the watched source is checked
+// for changes. The default interval is one second. Non-positive durations
+// are ignored.
+func WatchInterval(d time.Duration) WatchOption {
+	return func(o *watchOptions) {
+		if d > 0 {
+			o.interval = d
+		}
+	}
+}
+
+// WatchDebounce sets the time a changed file has to remain unchanged before
+// it is reloaded. This prevents reloading files which are still being
+// written. The default is 100 milliseconds. Non-positive durations are
+// ignored.
+func WatchDebounce(d time.Duration) WatchOption {
+	return func(o *watchOptions) {
+		if d > 0 {
+			o.debounce = d
+		}
+	}
+}
+
+// WatchBackoff sets the maximum time between two requests of a watched URL
+// after failed requests. After each consecutive failure the time until the
+// next request is doubled, starting with the watch interval, until max is
+// reached. The default maximum is one minute. Non-positive durations are
+// ignored.
+func WatchBackoff(max time.Duration) WatchOption {
+	return func(o *watchOptions) {
+		if max > 0 {
+			o.backoff = max
+		}
+	}
+}
+
+// WatchHTTPClient sets the client which is used to request a watched URL.
+// By default a client with a timeout of 30 seconds is used.
+func WatchHTTPClient(c *http.Client) WatchOption {
+	return func(o *watchOptions) {
+		o.client = c
+	}
+}
+
+// WatchErrors sets a function which is called with all errors occurring
+// while watching (e.g. the file cannot be read or parsed). By default
+// these errors are ignored.
+func WatchErrors(fn func(error)) WatchOption {
+	return func(o *watchOptions) {
+		o.onError = fn
+	}
+}
+
+// Watcher watches a configuration source and reloads it on changes.
+type Watcher struct {
+	poll pollFunc
+	fn   func(old, new Config)
+	opts watchOptions
+
+	mu     sync.RWMutex
+	config Config
+	errMsg string // last reported error
+
+	ctx    context.Context // cancelled by Close
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// pollFunc checks a watched source once. It returns the new configuration
+// if the source changed (nil otherwise) and the time to wait until the next
+// check. The context is cancelled when the watcher is closed.
+type pollFunc func(ctx context.Context) (Config, time.Duration, error)
+
+// Watch loads the configuration file with the given path and watches it
+// for changes. If unmarshal is nil, the parser is chosen by the file's
+// extension (see LoadFile). If the initial loading fails an error is
+// returned.
+//
+// The file is polled in a configurable interval, so watching works on all
+// platforms and file systems. A change is detected if the modification time
+// or the size of the file changes, or if the path refers to a different file
+// than before. The latter covers editors which write a new file and rename it
+// to the watched path as well as symlink swaps as used by Kubernetes for
+// mounted ConfigMaps. After a change the file has to remain unchanged for the
+// debounce time before it is reloaded. Changes of included files (see
+// IncludeKey) are not detected. The function fn is only called if the
+// file was parsed successfully and its contents changed. It is called with the
+// previous and the new configuration from a separate goroutine; calls are
+// never concurrent.
+func Watch(path string, unmarshal Unmarshaler, fn func(old, new Config), opts ...WatchOption) (*Watcher, error) {
+	if unmarshal == nil {
+		var err error
+		if unmarshal, err = lookupFormat(filepath.Ext(path)); err != nil {
+			return nil, err
+		}
+	}
+
+	o := newWatchOptions(opts)
+	p := &filePoller{
+		path:      path,
+		unmarshal: unmarshal,
+		opts:      o,
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	c, data, err := p.load()
+	if err != nil {
+		return nil, err
+	}
+	p.info = info
+	p.data = data
+
+	return startWatcher(c, p.poll, fn, o), nil
+}
+
+func newWatchOptions(opts []WatchOption) watchOptions {
+	o := watchOptions{
+		interval: time.Second,
+		debounce: 100 * time.Millisecond,
+		backoff:  time.Minute,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// startWatcher returns a watcher with the initial configuration c, which
+// polls the source with poll.
+func startWatcher(c Config, poll pollFunc, fn func(old, new Config), opts watchOptions) *Watcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &Watcher{
+		poll:   poll,
+		fn:     fn,
+		opts:   opts,
+		config: c,
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Config returns the most recently loaded configuration.
+func (w *Watcher) Config() Config {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.config
+}
+
+// Close stops watching the source. A pending request of a watched URL is
+// cancelled. Close waits until a running callback returned, so it must not
+// be called from within the callback.
+func (w *Watcher) Close() error {
+	w.cancel()
+	<-w.done
+	return nil
+}
+
+func (w *Watcher) run() {
+	defer close(w.done)
+
+	timer := time.NewTimer(w.opts.interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		c, next, err := w.poll(w.ctx)
+		if w.ctx.Err() != nil {
+			return // closed while polling
+		}
+		switch {
+		case err != nil:
+			w.report(err)
+		case c != nil:
+			w.errMsg = ""
+			w.update(c)
+		default:
+			w.errMsg = ""
+		}
+		timer.Reset(next)
+	}
+}
+
+func (w *Watcher) update(c Config) {
+	w.mu.Lock()
+	old := w.config
+	w.config = c
+	w.mu.Unlock()
+
+	if w.fn != nil {
+		w.fn(old, c)
+	}
+}
+
+// report passes err to the error handler, unless the same error was
+// reported before.
+func (w *Watcher) report(err error) {
+	if msg := err.Error(); msg != w.errMsg {
+		w.errMsg = msg
+		if w.opts.onError != nil {
+			w.opts.onError(err)
+		}
+	}
+}
+
+// filePoller polls a configuration file.
+type filePoller struct {
+	path      string
+	unmarshal Unmarshaler
+	opts      watchOptions
+
+	info    os.FileInfo
+	data    []byte
+	pending bool // file changed, waiting for the debounce time
+}
+
+func (p *filePoller) poll(ctx context.Context) (Config, time.Duration, error) {
+	info, err := os.Stat(p.path)
+	switch {
+	case err != nil:
+		// The file may be missing temporarily while it is replaced.
+		p.pending = false
+		return nil, p.opts.interval, err
+	case fileChanged(p.info, info):
+		p.info = info
+		p.pending = true
+		return nil, p.opts.debounce, nil
+	case !p.pending:
+		return nil, p.opts.interval, nil
+	}
+
+	p.pending = false
+	c, data, err := p.load()
+	if err != nil {
+		return nil, p.opts.interval, err
+	}
+	if bytes.Equal(data, p.data) {
+		return nil, p.opts.interval, nil
+	}
+	p.data = data
+	return c, p.opts.interval, nil
+}
+
+func (p *filePoller) load() (Config, []byte, error) {
+	data, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		return nil, nil, err
+	}
+	c, err := Load(bytes.NewReader(data), p.unmarshal)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = resolveIncludes(fileSystem{}, c, p.path, nil); err != nil {
+		return nil, nil, err
+	}
+	return c, data, nil
+}
+
+func fileChanged(prev, cur os.FileInfo) bool {
+	return prev == nil ||
+		!os.SameFile(prev, cur) ||
+		!prev.ModTime().Equal(cur.ModTime()) ||
+		prev.Size() != cur.Size()
+}
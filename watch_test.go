@@ -0,0 +1,155 @@
+package conf
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type watchEvent struct {
+	old, new Config
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTestFile(t, dir, "config.json", `{ "version": 1 }`)
+
+	events := make(chan watchEvent, 10)
+	errs := make(chan error, 10)
+	w, err := Watch(path, nil, func(old, new Config) {
+		events <- watchEvent{old: old, new: new}
+	}, WatchInterval(5*time.Millisecond), WatchDebounce(5*time.Millisecond), WatchErrors(func(err error) {
+		errs <- err
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer w.Close()
+
+	if v := w.Config()["version"]; v != 1.0 {
+		t.Fatalf("unexpected initial version: %v", v)
+	}
+
+	// in-place modification
+	writeTestFile(t, dir, "config.json", `{ "version": 2 }`)
+	ev := nextWatchEvent(t, events)
+	if ev.old["version"] != 1.0 || ev.new["version"] != 2.0 {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+
+	// rename and replace
+	tmp := writeTestFile(t, dir, "config.json.tmp", `{ "version": 3 }`)
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ev = nextWatchEvent(t, events)
+	if ev.old["version"] != 2.0 || ev.new["version"] != 3.0 {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+
+	// parse errors keep the previous configuration
+	writeTestFile(t, dir, "config.json", `{ "version": `)
+	select {
+	case <-errs:
+	case ev := <-events:
+		t.Fatalf("unexpected event: %+v", ev)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for error")
+	}
+	if v := w.Config()["version"]; v != 3.0 {
+		t.Fatalf("unexpected version: %v", v)
+	}
+
+	writeTestFile(t, dir, "config.json", `{ "version": 4 }`)
+	ev = nextWatchEvent(t, events)
+	if ev.old["version"] != 3.0 || ev.new["version"] != 4.0 {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+	if v := w.Config()["version"]; v != 4.0 {
+		t.Fatalf("unexpected version: %v", v)
+	}
+}
+
+func TestWatchSymlinkSwap(t *testing.T) {
+	// simulate the layout of a Kubernetes ConfigMap volume
+	dir := t.TempDir()
+	writeTestFile(t, dir, "data1/config.json", `{ "version": 1 }`)
+	writeTestFile(t, dir, "data2/config.json", `{ "version": 2 }`)
+	if err := os.Symlink("data1", filepath.Join(dir, "..data")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	path := filepath.Join(dir, "config.json")
+	if err := os.Symlink(filepath.Join("..data", "config.json"), path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	events := make(chan watchEvent, 10)
+	w, err := Watch(path, nil, func(old, new Config) {
+		events <- watchEvent{old: old, new: new}
+	}, WatchInterval(5*time.Millisecond), WatchDebounce(5*time.Millisecond))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer w.Close()
+
+	if err := os.Symlink("data2", filepath.Join(dir, "..data_tmp")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ev := nextWatchEvent(t, events)
+	if ev.old["version"] != 1.0 || ev.new["version"] != 2.0 {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+}
+
+func TestWatchErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := Watch(filepath.Join(dir, "missing.json"), nil, nil); err == nil {
+		t.Fatalf("expected error, got none")
+	}
+	if _, err := Watch(writeTestFile(t, dir, "config.unknown", ""), nil, nil); err == nil {
+		t.Fatalf("expected error, got none")
+	}
+	if _, err := Watch(writeTestFile(t, dir, "invalid.json", "{"), nil, nil); err == nil {
+		t.Fatalf("expected error, got none")
+	}
+}
+
+func TestWatchClose(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTestFile(t, dir, "config.json", `{ "version": 1 }`)
+
+	w, err := Watch(path, nil, nil, WatchInterval(0), WatchDebounce(-time.Second), WatchBackoff(0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w.opts.interval != time.Second || w.opts.debounce != 100*time.Millisecond || w.opts.backoff != time.Minute {
+		t.Fatalf("unexpected options: %+v", w.opts)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.Close()
+		}()
+	}
+	wg.Wait()
+}
+
+func nextWatchEvent(t *testing.T, events <-chan watchEvent) watchEvent {
+	select {
+	case ev := <-events:
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for change")
+	}
+	return watchEvent{}
+}